@@ -0,0 +1,128 @@
+package error
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/beka-birhanu/toddler/status"
+)
+
+// ExpectHTTPStatus checks that err would be served with the HTTP status want
+// and returns a descriptive error otherwise. Errors that are not *Error are
+// treated as 500s. It is meant for test assertions:
+//
+//	if err := error.ExpectHTTPStatus(got, http.StatusNotFound); err != nil {
+//		t.Error(err)
+//	}
+func ExpectHTTPStatus(err error, want int) error {
+	if err == nil {
+		return fmt.Errorf("expected HTTP status %d, got nil error", want)
+	}
+
+	var e *Error
+	if !errors.As(err, &e) {
+		if want != http.StatusInternalServerError {
+			return fmt.Errorf("expected HTTP status %d, got %d for non-toddler error: %v", want, http.StatusInternalServerError, err)
+		}
+		return nil
+	}
+	if e == nil {
+		return fmt.Errorf("expected HTTP status %d, got nil *Error", want)
+	}
+
+	if got := e.HTTPStatus(); got != want {
+		return fmt.Errorf(
+			"expected HTTP status %d, got %d (public status: %s (%d))",
+			want, got, status.GetErrorName(e.PublicStatusCode), e.PublicStatusCode,
+		)
+	}
+	return nil
+}
+
+// AssertNoLeak checks that none of e's service message or service metadata
+// values appear in publicJSON, the marshaled public representation of e.
+// Values that are legitimately public too (e.g. a resource name present in
+// both metadata maps) are ignored. It returns an error listing every leak.
+func AssertNoLeak(publicJSON []byte, e *Error) error {
+	public := []string{e.PublicMessage}
+	for key, value := range e.PublicMetaData {
+		public = append(public, key, value)
+	}
+	isPublic := func(s string) bool {
+		for _, p := range public {
+			if strings.Contains(p, s) {
+				return true
+			}
+		}
+		return false
+	}
+
+	candidates := map[string]string{"service message": e.ServiceMessage}
+	for key, value := range e.ServiceMetaData {
+		candidates[fmt.Sprintf("service metadata %q", key)] = value
+	}
+
+	var leaks []string
+	for source, value := range candidates {
+		if value == "" || isPublic(value) {
+			continue
+		}
+		if bytes.Contains(publicJSON, []byte(value)) || bytes.Contains(publicJSON, jsonEscaped(value)) {
+			leaks = append(leaks, source)
+		}
+	}
+	if len(leaks) == 0 {
+		return nil
+	}
+	sort.Strings(leaks)
+	return fmt.Errorf("public output leaks service data: %s", strings.Join(leaks, ", "))
+}
+
+// jsonEscaped returns s as it appears inside a JSON string literal.
+func jsonEscaped(s string) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return bytes.Trim(bytes.TrimSpace(buf.Bytes()), `"`)
+}
+
+// AssertablePublic returns the public side of e as a plain map that compares
+// reliably with reflect.DeepEqual or testify's assert.Equal. Metadata is
+// always a non-nil map, so a nil and an empty map compare equal, and no
+// unexported state is included.
+func (e *Error) AssertablePublic() map[string]any {
+	return map[string]any{
+		"status_code": int(e.PublicStatusCode),
+		"message":     e.PublicMessage,
+		"metadata":    assertableMetaData(e.PublicMetaData),
+	}
+}
+
+// AssertableService is like AssertablePublic for the service side of e. A
+// wrapped cause is represented by its message.
+func (e *Error) AssertableService() map[string]any {
+	cause := ""
+	if e.cause != nil {
+		cause = e.cause.Error()
+	}
+	return map[string]any{
+		"status_code": int(e.ServiceStatusCode),
+		"message":     e.ServiceMessage,
+		"metadata":    assertableMetaData(e.ServiceMetaData),
+		"cause":       cause,
+	}
+}
+
+func assertableMetaData(metaData map[string]string) map[string]string {
+	if metaData == nil {
+		return map[string]string{}
+	}
+	return maps.Clone(metaData)
+}
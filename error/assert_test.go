@@ -0,0 +1,98 @@
+package error_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/beka-birhanu/toddler/error"
+	"github.com/beka-birhanu/toddler/status"
+)
+
+func TestExpectHTTPStatus(t *testing.T) {
+	notFound := error.Must(status.NotFoundResource)
+
+	if err := error.ExpectHTTPStatus(notFound, http.StatusNotFound); err != nil {
+		t.Errorf("expected match, got: %v", err)
+	}
+	if err := error.ExpectHTTPStatus(notFound, http.StatusBadRequest); err == nil {
+		t.Error("expected a mismatch to be reported")
+	}
+	if err := error.ExpectHTTPStatus(errors.New("boom"), http.StatusInternalServerError); err != nil {
+		t.Errorf("expected plain errors to be treated as 500, got: %v", err)
+	}
+	if err := error.ExpectHTTPStatus(nil, http.StatusNotFound); err == nil {
+		t.Error("expected a nil error to be reported")
+	}
+}
+
+func TestAssertNoLeak(t *testing.T) {
+	err := &error.Error{
+		PublicStatusCode:  status.NotFoundResource,
+		ServiceStatusCode: status.NotFoundResource,
+		PublicMessage:     "Either user does not exist or you don't have access",
+		ServiceMessage:    "No record found for user: sql: no rows in result set",
+		PublicMetaData:    map[string]string{"resourceName": "user"},
+		ServiceMetaData: map[string]string{
+			"resourceName": "user",
+			"raw_error":    "sql: no rows in result set",
+		},
+	}
+
+	publicJSON, _ := json.Marshal(err)
+	if leakErr := error.AssertNoLeak(publicJSON, err); leakErr != nil {
+		t.Errorf("expected MarshalJSON output not to leak, got: %v", leakErr)
+	}
+
+	// A custom marshaler that (wrongly) includes the service message.
+	leakyJSON, _ := json.Marshal(map[string]any{
+		"message": err.PublicMessage,
+		"debug":   err.ServiceMessage,
+	})
+	leakErr := error.AssertNoLeak(leakyJSON, err)
+	if leakErr == nil {
+		t.Fatal("expected the leaky marshaler to be detected")
+	}
+	expected := `public output leaks service data: service message, service metadata "raw_error"`
+	if leakErr.Error() != expected {
+		t.Errorf("unexpected leak report.\nExpected: %s\nGot: %s", expected, leakErr)
+	}
+}
+
+func TestAssertable(t *testing.T) {
+	build := func(publicMeta map[string]string) *error.Error {
+		return &error.Error{
+			PublicStatusCode:  status.NotFoundResource,
+			ServiceStatusCode: status.NotFoundResource,
+			PublicMessage:     "Either user does not exist or you don't have access",
+			ServiceMessage:    "No record found for user",
+			PublicMetaData:    publicMeta,
+			ServiceMetaData:   map[string]string{"resourceName": "user", "raw_error": "sql: no rows in result set"},
+		}
+	}
+
+	a, b := build(nil), build(map[string]string{})
+	if !reflect.DeepEqual(a.AssertablePublic(), b.AssertablePublic()) {
+		t.Errorf("expected equal public maps:\n%v\n%v", a.AssertablePublic(), b.AssertablePublic())
+	}
+	if !reflect.DeepEqual(a.AssertableService(), b.AssertableService()) {
+		t.Errorf("expected equal service maps:\n%v\n%v", a.AssertableService(), b.AssertableService())
+	}
+
+	c := build(nil)
+	c.ServiceMetaData["raw_error"] = "connection reset"
+	if reflect.DeepEqual(a.AssertableService(), c.AssertableService()) {
+		t.Error("expected differing service metadata to compare unequal")
+	}
+
+	expected := map[string]any{
+		"status_code": int(status.NotFoundResource),
+		"message":     "Either user does not exist or you don't have access",
+		"metadata":    map[string]string{},
+	}
+	if got := a.AssertablePublic(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected public map.\nExpected: %v\nGot: %v", expected, got)
+	}
+}
@@ -0,0 +1,51 @@
+package error
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/beka-birhanu/toddler/status"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// FromAuthError maps password-hashing errors into structured application
+// errors. A bcrypt password mismatch becomes UnauthorizedInvalidCredential
+// with a generic public message that never reveals which factor failed; a
+// malformed stored hash is a server-side problem and becomes a ServerError.
+// It returns nil for errors it does not recognize.
+func FromAuthError(err error) *Error {
+	switch {
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return &Error{
+			PublicStatusCode:  status.UnauthorizedInvalidCredential,
+			ServiceStatusCode: status.UnauthorizedInvalidCredential,
+			PublicMessage:     "Invalid credentials",
+			PublicMetaData: map[string]string{
+				"error_type": AuthenticationFailed.String(),
+			},
+			ServiceMessage: fmt.Sprintf("Password does not match stored hash: %s", err),
+			ServiceMetaData: map[string]string{
+				"error_type": PasswordMismatch.String(),
+				"raw_error":  err.Error(),
+			},
+			cause: err,
+		}
+	case errors.Is(err, bcrypt.ErrHashTooShort):
+		return &Error{
+			PublicStatusCode:  status.ServerError,
+			ServiceStatusCode: status.ServerError,
+			PublicMessage:     genericServerMessage,
+			PublicMetaData: map[string]string{
+				"error_type": InternalServerError.String(),
+			},
+			ServiceMessage: fmt.Sprintf("Stored password hash is malformed: %s", err),
+			ServiceMetaData: map[string]string{
+				"error_type": MalformedPasswordHash.String(),
+				"raw_error":  err.Error(),
+			},
+			cause: err,
+		}
+	default:
+		return nil
+	}
+}
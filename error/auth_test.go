@@ -0,0 +1,38 @@
+package error_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/beka-birhanu/toddler/error"
+	"github.com/beka-birhanu/toddler/status"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestFromAuthError_Mismatch(t *testing.T) {
+	err := error.FromAuthError(fmt.Errorf("login: %w", bcrypt.ErrMismatchedHashAndPassword))
+
+	if err.PublicStatusCode != status.UnauthorizedInvalidCredential {
+		t.Errorf("unexpected public status: %d", err.PublicStatusCode)
+	}
+	if err.HTTPStatus() != 401 {
+		t.Errorf("unexpected HTTP status: %d", err.HTTPStatus())
+	}
+	if strings.Contains(strings.ToLower(err.PublicMessage), "password") {
+		t.Errorf("expected a generic public message, got %q", err.PublicMessage)
+	}
+	if !errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		t.Error("expected the bcrypt error to be unwrappable")
+	}
+}
+
+func TestFromAuthError_Unrecognized(t *testing.T) {
+	if err := error.FromAuthError(errors.New("boom")); err != nil {
+		t.Errorf("expected nil for an unrecognized error, got %v", err)
+	}
+	if err := error.FromAuthError(bcrypt.ErrHashTooShort); err.HTTPStatus() != 500 {
+		t.Errorf("expected a malformed hash to be a server error, got %d", err.HTTPStatus())
+	}
+}
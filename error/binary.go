@@ -0,0 +1,56 @@
+package error
+
+import (
+	"bytes"
+	"encoding/gob"
+
+	"github.com/beka-birhanu/toddler/status"
+)
+
+// binaryError is the gob representation of an Error. The wrapped cause and
+// per-field validation errors are not part of it.
+type binaryError struct {
+	PublicStatusCode  status.StatusCode
+	ServiceStatusCode status.StatusCode
+	PublicMessage     string
+	ServiceMessage    string
+	PublicMetaData    map[string]string
+	ServiceMetaData   map[string]string
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler using gob. The output
+// includes service data and is meant for caches within the trust boundary,
+// never for clients.
+func (e *Error) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(binaryError{
+		PublicStatusCode:  e.PublicStatusCode,
+		ServiceStatusCode: e.ServiceStatusCode,
+		PublicMessage:     e.PublicMessage,
+		ServiceMessage:    e.ServiceMessage,
+		PublicMetaData:    e.PublicMetaData,
+		ServiceMetaData:   e.ServiceMetaData,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, decoding data
+// produced by MarshalBinary into the receiver.
+func (e *Error) UnmarshalBinary(data []byte) error {
+	var b binaryError
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&b); err != nil {
+		return err
+	}
+	*e = Error{
+		PublicStatusCode:  b.PublicStatusCode,
+		ServiceStatusCode: b.ServiceStatusCode,
+		PublicMessage:     b.PublicMessage,
+		ServiceMessage:    b.ServiceMessage,
+		PublicMetaData:    b.PublicMetaData,
+		ServiceMetaData:   b.ServiceMetaData,
+	}
+	return nil
+}
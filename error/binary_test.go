@@ -0,0 +1,47 @@
+package error_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/beka-birhanu/toddler/error"
+	"github.com/beka-birhanu/toddler/status"
+)
+
+func TestError_MarshalBinary(t *testing.T) {
+	original := &error.Error{
+		PublicStatusCode:  status.NotFoundResource,
+		ServiceStatusCode: status.ServerErrorDatabase,
+		PublicMessage:     "Either user does not exist or you don't have access",
+		ServiceMessage:    "No record found for user",
+		PublicMetaData:    map[string]string{"resourceName": "user"},
+		ServiceMetaData:   map[string]string{"resourceName": "user", "raw_error": "sql: no rows in result set"},
+	}
+
+	data, err := original.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+
+	var decoded error.Error
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary failed: %v", err)
+	}
+
+	if decoded.PublicStatusCode != original.PublicStatusCode || decoded.ServiceStatusCode != original.ServiceStatusCode {
+		t.Errorf("status codes did not round-trip: got %d/%d", decoded.PublicStatusCode, decoded.ServiceStatusCode)
+	}
+	if decoded.PublicMessage != original.PublicMessage || decoded.ServiceMessage != original.ServiceMessage {
+		t.Errorf("messages did not round-trip: got %q/%q", decoded.PublicMessage, decoded.ServiceMessage)
+	}
+	if !reflect.DeepEqual(decoded.PublicMetaData, original.PublicMetaData) {
+		t.Errorf("public metadata did not round-trip: %v", decoded.PublicMetaData)
+	}
+	if !reflect.DeepEqual(decoded.ServiceMetaData, original.ServiceMetaData) {
+		t.Errorf("service metadata did not round-trip: %v", decoded.ServiceMetaData)
+	}
+
+	if err := decoded.UnmarshalBinary([]byte("garbage")); err == nil {
+		t.Error("expected invalid data to be rejected")
+	}
+}
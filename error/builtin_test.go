@@ -0,0 +1,5 @@
+package error_test
+
+// builtinError names the predeclared error type, which test files importing
+// this package under its own name cannot refer to directly.
+type builtinError = error
@@ -0,0 +1,190 @@
+package error
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/beka-birhanu/toddler/status"
+)
+
+// CreateRace builds a conflict error for two requests racing to create the
+// same entity. It stays retryable while attemptsLeft is positive so callers
+// can run a bounded retry loop.
+func CreateRace(entity string, attemptsLeft int) *Error {
+	if attemptsLeft < 0 {
+		attemptsLeft = 0
+	}
+	e := &Error{
+		PublicStatusCode:  status.ConflictDuplicateData,
+		ServiceStatusCode: status.ConflictDuplicateData,
+		PublicMessage:     fmt.Sprintf("A %s with the same value already exists", entity),
+		PublicMetaData: map[string]string{
+			"error_type":   DataDuplication.String(),
+			"resourceName": entity,
+		},
+		ServiceMessage: fmt.Sprintf("Concurrent create race on %s, %d attempts left", entity, attemptsLeft),
+		ServiceMetaData: map[string]string{
+			"error_type":    ConcurrentCreate.String(),
+			"resourceName":  entity,
+			"attempts_left": strconv.Itoa(attemptsLeft),
+		},
+	}
+	return e.WithRetryable(attemptsLeft > 0)
+}
+
+// MethodNotAllowed builds an error for a request using an unsupported HTTP
+// method. The allowed methods are recorded in public metadata and emitted as
+// the Allow header by HeaderMap.
+func MethodNotAllowed(allowed []string) *Error {
+	allowedMethods := strings.Join(allowed, ", ")
+	return &Error{
+		PublicStatusCode:  status.BadRequestMethodNotAllowed,
+		ServiceStatusCode: status.BadRequestMethodNotAllowed,
+		PublicMessage:     "The HTTP method is not allowed for this resource",
+		PublicMetaData: map[string]string{
+			"error_type":        DisallowedMethod.String(),
+			metaKeyAllowMethods: allowedMethods,
+		},
+		ServiceMessage: fmt.Sprintf("Method not allowed, allowed methods: %s", allowedMethods),
+		ServiceMetaData: map[string]string{
+			"error_type":        DisallowedMethod.String(),
+			metaKeyAllowMethods: allowedMethods,
+		},
+	}
+}
+
+// ExpectationFailed builds an error for an Expect header, such as
+// "Expect: 100-continue", or another conditional request header the server
+// cannot satisfy, other than a failed version precondition. detail is
+// shown to the client; when empty, a generic message is used.
+func ExpectationFailed(detail string) *Error {
+	publicMessage := detail
+	if publicMessage == "" {
+		publicMessage = status.DefaultMessage(status.BadRequestExpectationFailed)
+	}
+	return &Error{
+		PublicStatusCode:  status.BadRequestExpectationFailed,
+		ServiceStatusCode: status.BadRequestExpectationFailed,
+		PublicMessage:     publicMessage,
+		PublicMetaData: map[string]string{
+			"error_type": UnmetExpectation.String(),
+		},
+		ServiceMessage: fmt.Sprintf("Expectation failed: %s", publicMessage),
+		ServiceMetaData: map[string]string{
+			"error_type": UnmetExpectation.String(),
+		},
+	}
+}
+
+// ForbiddenState builds an error for an action that is not allowed because
+// of the entity's current state (e.g. editing a locked record), as opposed
+// to a lack of privileges.
+func ForbiddenState(entity, state string) *Error {
+	return &Error{
+		PublicStatusCode:  status.ForbiddenResourceState,
+		ServiceStatusCode: status.ForbiddenResourceState,
+		PublicMessage:     fmt.Sprintf("This action is not allowed while the %s is %s", entity, state),
+		PublicMetaData: map[string]string{
+			"error_type":   ResourceState.String(),
+			"resourceName": entity,
+			"state":        state,
+		},
+		ServiceMessage: fmt.Sprintf("Action forbidden on %s in state %q", entity, state),
+		ServiceMetaData: map[string]string{
+			"error_type":   ResourceState.String(),
+			"resourceName": entity,
+			"state":        state,
+		},
+	}
+}
+
+// ServiceCommunicationError builds an error for a failed call to the
+// dependency named service. The service name is recorded in both metadata
+// maps and cause is available through Unwrap.
+func ServiceCommunicationError(service string, cause error) *Error {
+	e := Wrap(status.ServerErrorServiceCommunication, cause)
+	e.PublicMetaData["error_type"] = ServiceCommunication.String()
+	e.PublicMetaData["service"] = service
+	e.ServiceMetaData["error_type"] = ServiceCommunication.String()
+	e.ServiceMetaData["service"] = service
+	if cause != nil {
+		e.ServiceMessage = fmt.Sprintf("Call to %s failed: %s", service, cause)
+	} else {
+		e.ServiceMessage = fmt.Sprintf("Call to %s failed", service)
+	}
+	return e
+}
+
+// Locked builds an error for an action on an entity that is currently locked.
+func Locked(entity string) *Error {
+	return &Error{
+		PublicStatusCode:  status.ConflictLocked,
+		ServiceStatusCode: status.ConflictLocked,
+		PublicMessage:     fmt.Sprintf("The %s is locked", entity),
+		PublicMetaData: map[string]string{
+			"error_type":   ResourceLocked.String(),
+			"resourceName": entity,
+		},
+		ServiceMessage: fmt.Sprintf("Attempted to modify locked %s", entity),
+		ServiceMetaData: map[string]string{
+			"error_type":   ResourceLocked.String(),
+			"resourceName": entity,
+		},
+	}
+}
+
+// Must returns a minimal error for code carrying the code's default public
+// message. It panics if code is not registered, which makes it suitable for
+// test fixtures and startup invariants only.
+func Must(code status.StatusCode) *Error {
+	if !status.IsRegistered(code) {
+		panic(fmt.Sprintf("error: unregistered status code %d", code))
+	}
+	return &Error{
+		PublicStatusCode:  code,
+		ServiceStatusCode: code,
+		PublicMessage:     defaultPublicMessage(code),
+		ServiceMessage:    status.DefaultMessage(code),
+		PublicMetaData:    map[string]string{},
+		ServiceMetaData:   map[string]string{},
+	}
+}
+
+// Recover converts a value obtained from recover() into a ServerError. The
+// panic message and stack trace are kept in service metadata only.
+// It returns nil when recovered is nil, so it can be called unconditionally:
+//
+//	defer func() {
+//		if e := error.Recover(recover()); e != nil {
+//			// write e
+//		}
+//	}()
+func Recover(recovered any) *Error {
+	if recovered == nil {
+		return nil
+	}
+
+	var panicMessage string
+	if err, ok := recovered.(error); ok {
+		panicMessage = err.Error()
+	} else {
+		panicMessage = fmt.Sprintf("%v", recovered)
+	}
+
+	return &Error{
+		PublicStatusCode:  status.ServerError,
+		ServiceStatusCode: status.ServerError,
+		PublicMessage:     genericServerMessage,
+		PublicMetaData: map[string]string{
+			"error_type": InternalServerError.String(),
+		},
+		ServiceMessage: fmt.Sprintf("Recovered from panic: %s", panicMessage),
+		ServiceMetaData: map[string]string{
+			"error_type":    Panic.String(),
+			"panic_message": panicMessage,
+			"stack":         string(debug.Stack()),
+		},
+	}
+}
@@ -0,0 +1,150 @@
+package error_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/beka-birhanu/toddler/error"
+	"github.com/beka-birhanu/toddler/status"
+)
+
+func TestCreateRace(t *testing.T) {
+	err := error.CreateRace("user", 2)
+	if err.PublicStatusCode != status.ConflictDuplicateData {
+		t.Errorf("unexpected public status: %d", err.PublicStatusCode)
+	}
+	if got := err.ServiceMetaData["attempts_left"]; got != "2" {
+		t.Errorf("unexpected attempts_left: %q", got)
+	}
+	if !err.IsRetryable() {
+		t.Error("expected race with attempts left to be retryable")
+	}
+
+	if error.CreateRace("user", 0).IsRetryable() {
+		t.Error("expected race with no attempts left to not be retryable")
+	}
+}
+
+func TestRecover(t *testing.T) {
+	for _, recovered := range []any{"boom", errors.New("boom")} {
+		err := error.Recover(recovered)
+		if err == nil {
+			t.Fatalf("expected an error for panic value %v", recovered)
+		}
+		if err.PublicStatusCode < 5000 {
+			t.Errorf("expected a 5xx status, got %d", err.PublicStatusCode)
+		}
+		if got := err.ServiceMetaData["panic_message"]; got != "boom" {
+			t.Errorf("unexpected panic_message: %q", got)
+		}
+		if err.ServiceMetaData["stack"] == "" {
+			t.Error("expected a stack trace in service metadata")
+		}
+		if _, ok := err.PublicMetaData["stack"]; ok {
+			t.Error("stack trace must not be public")
+		}
+	}
+
+	if error.Recover(nil) != nil {
+		t.Error("expected nil for a nil recovered value")
+	}
+}
+
+func TestMust(t *testing.T) {
+	err := error.Must(status.NotFoundResource)
+	if err.PublicStatusCode != status.NotFoundResource || err.ServiceStatusCode != status.NotFoundResource {
+		t.Errorf("unexpected status codes: %d/%d", err.PublicStatusCode, err.ServiceStatusCode)
+	}
+	if err.PublicMessage != status.DefaultMessage(status.NotFoundResource) {
+		t.Errorf("unexpected public message: %q", err.PublicMessage)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected Must to panic for an unregistered code")
+		}
+	}()
+	error.Must(status.StatusCode(1234))
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	err := error.MethodNotAllowed([]string{"GET", "POST"})
+
+	if err.HTTPStatus() != http.StatusMethodNotAllowed {
+		t.Errorf("unexpected HTTP status: %d", err.HTTPStatus())
+	}
+
+	headers := err.HeaderMap()
+	if got := headers["Allow"]; got != "GET, POST" {
+		t.Errorf("unexpected Allow header: %q", got)
+	}
+	if got := headers["X-Error-Code"]; got != "4008" {
+		t.Errorf("unexpected X-Error-Code header: %q", got)
+	}
+}
+
+func TestForbiddenState(t *testing.T) {
+	err := error.ForbiddenState("invoice", "locked")
+
+	if err.PublicStatusCode != status.ForbiddenResourceState {
+		t.Errorf("unexpected public status: %d", err.PublicStatusCode)
+	}
+	if err.HTTPStatus() != http.StatusForbidden {
+		t.Errorf("unexpected HTTP status: %d", err.HTTPStatus())
+	}
+	if got := err.PublicMetaData["state"]; got != "locked" {
+		t.Errorf("unexpected state metadata: %q", got)
+	}
+	if err.PublicMessage != "This action is not allowed while the invoice is locked" {
+		t.Errorf("unexpected public message: %q", err.PublicMessage)
+	}
+}
+
+func TestExpectationFailed(t *testing.T) {
+	err := error.ExpectationFailed("100-continue is not supported")
+
+	if err.PublicStatusCode != status.BadRequestExpectationFailed {
+		t.Errorf("unexpected public status: %d", err.PublicStatusCode)
+	}
+	if err.HTTPStatus() != http.StatusExpectationFailed {
+		t.Errorf("unexpected HTTP status: %d", err.HTTPStatus())
+	}
+	if err.PublicMessage != "100-continue is not supported" {
+		t.Errorf("unexpected public message: %q", err.PublicMessage)
+	}
+
+	if got := error.ExpectationFailed("").PublicMessage; got != "The request's expectations could not be met." {
+		t.Errorf("unexpected default public message: %q", got)
+	}
+}
+
+func TestServiceCommunicationError(t *testing.T) {
+	cause := errors.New("connection refused")
+
+	err := error.ServiceCommunicationError("billing", cause)
+
+	if err.ServiceStatusCode != status.ServerErrorServiceCommunication {
+		t.Errorf("unexpected service status: %d", err.ServiceStatusCode)
+	}
+	if err.PublicMetaData["service"] != "billing" || err.ServiceMetaData["service"] != "billing" {
+		t.Errorf("expected service name in both metadata maps, got %v / %v", err.PublicMetaData, err.ServiceMetaData)
+	}
+	if !errors.Is(err, cause) {
+		t.Error("expected the cause to be unwrappable")
+	}
+}
+
+func TestLocked(t *testing.T) {
+	err := error.Locked("document")
+
+	if err.PublicStatusCode != status.ConflictLocked {
+		t.Errorf("unexpected public status: %d", err.PublicStatusCode)
+	}
+	if err.HTTPStatus() != http.StatusLocked {
+		t.Errorf("unexpected HTTP status: %d", err.HTTPStatus())
+	}
+	if got := err.PublicMetaData["resourceName"]; got != "document" {
+		t.Errorf("unexpected resourceName metadata: %q", got)
+	}
+}
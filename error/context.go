@@ -0,0 +1,52 @@
+package error
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/beka-birhanu/toddler/status"
+)
+
+// FromContextError maps context errors into structured application errors:
+// context.Canceled, usually a client disconnect, becomes
+// BadRequestClientClosed (HTTP 499) so it doesn't count as a server error,
+// and context.DeadlineExceeded becomes a retryable ServerErrorTimeout.
+// It returns nil for errors that are not context errors.
+func FromContextError(err error) *Error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return &Error{
+			PublicStatusCode:  status.BadRequestClientClosed,
+			ServiceStatusCode: status.BadRequestClientClosed,
+			PublicMessage:     "The request was canceled by the client",
+			PublicMetaData: map[string]string{
+				"error_type": ClientClosedRequest.String(),
+			},
+			ServiceMessage: fmt.Sprintf("Request canceled: %s", err),
+			ServiceMetaData: map[string]string{
+				"error_type": ContextCanceled.String(),
+				"raw_error":  err.Error(),
+			},
+			cause: err,
+		}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &Error{
+			PublicStatusCode:  status.ServerErrorTimeout,
+			ServiceStatusCode: status.ServerErrorTimeout,
+			PublicMessage:     "The request took too long to complete. Please try again later.",
+			PublicMetaData: map[string]string{
+				"error_type": Timeout.String(),
+			},
+			ServiceMessage: fmt.Sprintf("Deadline exceeded: %s", err),
+			ServiceMetaData: map[string]string{
+				"error_type":     ContextDeadlineExceeded.String(),
+				"raw_error":      err.Error(),
+				metaKeyRetryable: "true",
+			},
+			cause: err,
+		}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,43 @@
+package error_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/beka-birhanu/toddler/error"
+	"github.com/beka-birhanu/toddler/status"
+)
+
+func TestFromContextError_Canceled(t *testing.T) {
+	err := error.FromContextError(fmt.Errorf("query: %w", context.Canceled))
+
+	if err.PublicStatusCode != status.BadRequestClientClosed {
+		t.Errorf("unexpected public status: %d", err.PublicStatusCode)
+	}
+	if err.HTTPStatus() != 499 {
+		t.Errorf("unexpected HTTP status: %d", err.HTTPStatus())
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Error("expected the context error to be unwrappable")
+	}
+}
+
+func TestFromContextError_DeadlineExceeded(t *testing.T) {
+	err := error.FromContextError(context.DeadlineExceeded)
+
+	if err.PublicStatusCode != status.ServerErrorTimeout {
+		t.Errorf("unexpected public status: %d", err.PublicStatusCode)
+	}
+	if err.HTTPStatus() != 504 {
+		t.Errorf("unexpected HTTP status: %d", err.HTTPStatus())
+	}
+	if !err.IsRetryable() {
+		t.Error("expected a deadline error to be retryable")
+	}
+
+	if error.FromContextError(errors.New("plain")) != nil {
+		t.Error("expected nil for a non-context error")
+	}
+}
@@ -0,0 +1,105 @@
+package error
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/beka-birhanu/toddler/status"
+)
+
+// FromJSONError maps errors returned while decoding a JSON request body into
+// structured application errors. Type errors become BadRequestTypeMismatch
+// with the offending field; syntax and other decoding errors become BadRequest.
+// The public message names the expected JSON kind (e.g. "number"), keeping the
+// Go type in service metadata only.
+func FromJSONError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		expected := typeErr.Type.String()
+		kind := jsonKind(typeErr.Type)
+		return &Error{
+			PublicStatusCode:  status.BadRequestTypeMismatch,
+			ServiceStatusCode: status.BadRequestTypeMismatch,
+			PublicMessage:     fmt.Sprintf("%s must be of type %s", typeErr.Field, kind),
+			PublicMetaData: map[string]string{
+				"error_type":    TypeMismatch.String(),
+				"field":         typeErr.Field,
+				"expected_type": kind,
+			},
+			ServiceMessage: fmt.Sprintf("JSON type mismatch on field '%s': got %s, expected %s", typeErr.Field, typeErr.Value, expected),
+			ServiceMetaData: map[string]string{
+				"error_type":    JSONTypeMismatch.String(),
+				"field":         typeErr.Field,
+				"expected_type": expected,
+				"actual_type":   typeErr.Value,
+				"offset":        strconv.FormatInt(typeErr.Offset, 10),
+				"raw_error":     err.Error(),
+			},
+		}
+	}
+
+	serviceMeta := map[string]string{
+		"error_type": JSONDecoding.String(),
+		"raw_error":  err.Error(),
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		serviceMeta["error_type"] = JSONSyntax.String()
+		serviceMeta["offset"] = strconv.FormatInt(syntaxErr.Offset, 10)
+	}
+
+	return &Error{
+		PublicStatusCode:  status.BadRequest,
+		ServiceStatusCode: status.BadRequest,
+		PublicMessage:     "The request body is not valid JSON",
+		PublicMetaData: map[string]string{
+			"error_type": MalformedJSON.String(),
+		},
+		ServiceMessage:  fmt.Sprintf("Failed to decode JSON body: %s", err),
+		ServiceMetaData: serviceMeta,
+	}
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// jsonKind names the JSON value kind a Go type decodes from, so public
+// messages do not leak Go type names.
+func jsonKind(t reflect.Type) string {
+	if t == nil {
+		return "value"
+	}
+	if t.Implements(textUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return "string"
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return jsonKind(t.Elem())
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string"
+		}
+		return "array"
+	case reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return "value"
+	}
+}
@@ -0,0 +1,79 @@
+package error_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/beka-birhanu/toddler/error"
+	"github.com/beka-birhanu/toddler/status"
+)
+
+func TestFromJSONError_TypeMismatch(t *testing.T) {
+	var input struct {
+		Age int `json:"age"`
+	}
+	decodeErr := json.Unmarshal([]byte(`{"age": "ten"}`), &input)
+
+	err := error.FromJSONError(decodeErr)
+
+	if err.PublicStatusCode != status.BadRequestTypeMismatch {
+		t.Errorf("unexpected public status: %d", err.PublicStatusCode)
+	}
+	if got := err.PublicMetaData["field"]; got != "age" {
+		t.Errorf("unexpected field metadata: %q", got)
+	}
+	if got := err.PublicMetaData["expected_type"]; got != "number" {
+		t.Errorf("unexpected expected_type metadata: %q", got)
+	}
+	if err.PublicMessage != "age must be of type number" {
+		t.Errorf("unexpected public message: %q", err.PublicMessage)
+	}
+	if got := err.ServiceMetaData["expected_type"]; got != "int" {
+		t.Errorf("expected the Go type in service metadata, got %q", got)
+	}
+}
+
+func TestFromJSONError_TypeMismatchKinds(t *testing.T) {
+	tests := []struct {
+		name   string
+		target any
+		body   string
+		kind   string
+	}{
+		{"number", new(struct{ V float64 }), `{"V": "x"}`, "number"},
+		{"boolean", new(struct{ V bool }), `{"V": 1}`, "boolean"},
+		{"string", new(struct{ V string }), `{"V": 1}`, "string"},
+		{"array", new(struct{ V []int }), `{"V": 1}`, "array"},
+		{"object", new(struct{ V map[string]int }), `{"V": 1}`, "object"},
+		{"struct", new(struct{ V struct{ A int } }), `{"V": 1}`, "object"},
+		{"pointer", new(struct{ V *int }), `{"V": "x"}`, "number"},
+		{"time", new(struct{ V time.Time }), `{"V": 1}`, "string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := error.FromJSONError(json.Unmarshal([]byte(tt.body), tt.target))
+			if err.PublicStatusCode != status.BadRequestTypeMismatch {
+				t.Fatalf("unexpected public status: %d", err.PublicStatusCode)
+			}
+			if got := err.PublicMetaData["expected_type"]; got != tt.kind {
+				t.Errorf("unexpected expected_type.\nExpected: %q\nGot: %q", tt.kind, got)
+			}
+		})
+	}
+}
+
+func TestFromJSONError_Syntax(t *testing.T) {
+	var input map[string]any
+	decodeErr := json.Unmarshal([]byte(`{"age": `), &input)
+
+	err := error.FromJSONError(decodeErr)
+
+	if err.PublicStatusCode != status.BadRequest {
+		t.Errorf("unexpected public status: %d", err.PublicStatusCode)
+	}
+	if got := err.ServiceMetaData["error_type"]; got != "JSON syntax" {
+		t.Errorf("unexpected error_type metadata: %q", got)
+	}
+}
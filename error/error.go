@@ -9,13 +9,81 @@
 package error
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
 
 	"github.com/beka-birhanu/toddler/status"
 )
 
+// ErrorTypes classifies an error under the "error_type" metadata key.
 type ErrorTypes string
 
+// Validation and request decoding.
+const (
+	Validation            ErrorTypes = "Validation"
+	ValidatorFieldErrors  ErrorTypes = "ValidatorFieldErrors"
+	ValidatorEmptyErrors  ErrorTypes = "ValidatorEmptyErrors"
+	ValidatorErrorUnknown ErrorTypes = "ValidatorErrorUnknown"
+	TypeMismatch          ErrorTypes = "Type mismatch"
+	JSONTypeMismatch      ErrorTypes = "JSON type mismatch"
+	JSONDecoding          ErrorTypes = "JSON decoding"
+	JSONSyntax            ErrorTypes = "JSON syntax"
+	MalformedJSON         ErrorTypes = "Malformed JSON"
+	DisallowedMethod      ErrorTypes = "Method not allowed"
+	UnmetExpectation      ErrorTypes = "Expectation failed"
+)
+
+// Authentication.
+const (
+	AuthenticationFailed  ErrorTypes = "Authentication failed"
+	PasswordMismatch      ErrorTypes = "Password mismatch"
+	MalformedPasswordHash ErrorTypes = "Malformed password hash"
+)
+
+// Data and resource state.
+const (
+	DataNotFound          ErrorTypes = "Data not found"
+	DataDuplication       ErrorTypes = "Data duplication"
+	ConcurrentCreate      ErrorTypes = "Create race"
+	ForeignKeyViolation   ErrorTypes = "Foreign key violation"
+	MissingField          ErrorTypes = "Missing field"
+	ConstraintCheckFailed ErrorTypes = "Constraint check failed"
+	ResourceInUse         ErrorTypes = "Resource in use"
+	ResourceState         ErrorTypes = "Resource state"
+	ResourceLocked        ErrorTypes = "Locked"
+	LockContention        ErrorTypes = "Lock contention"
+	LockWaitTimeout       ErrorTypes = "Lock wait timeout"
+	Deadlock              ErrorTypes = "Deadlock"
+)
+
+// Server, database and downstream failures.
+const (
+	InternalServerError          ErrorTypes = "Internal server error"
+	InternalDatabaseError        ErrorTypes = "Internal database error"
+	UnknownServerError           ErrorTypes = "Unknown server error"
+	UnknownDatabaseError         ErrorTypes = "Unknown database error"
+	DatabaseConnection           ErrorTypes = "Database connection"
+	QueryCanceled                ErrorTypes = "Query canceled"
+	Timeout                      ErrorTypes = "Timeout"
+	ContextCanceled              ErrorTypes = "Context canceled"
+	ContextDeadlineExceeded      ErrorTypes = "Context deadline exceeded"
+	ClientClosedRequest          ErrorTypes = "Client closed request"
+	ServiceCommunication         ErrorTypes = "Service communication"
+	DownstreamError              ErrorTypes = "Downstream error"
+	DownstreamErrorInSuccessBody ErrorTypes = "Downstream error in success body"
+	Panic                        ErrorTypes = "Panic"
+)
+
+// String returns the value recorded under the "error_type" metadata key.
+func (t ErrorTypes) String() string {
+	return string(t)
+}
+
 type Error struct {
 	PublicStatusCode  status.StatusCode
 	ServiceStatusCode status.StatusCode
@@ -23,24 +91,98 @@ type Error struct {
 	ServiceMessage    string
 	PublicMetaData    map[string]string
 	ServiceMetaData   map[string]string
+
+	// cause is the underlying error, exposed through Unwrap.
+	cause error
+
+	// fieldErrors holds the per-field failures of a validation error.
+	fieldErrors []*FieldValidationError
 }
 
 // Error implements the error interface.
 // Return a formatted string with status code, messages, and metadata
 func (e *Error) Error() string {
 	return fmt.Sprintf(
-		"{publicStatus: %s (%d), serviceStatus: %s (%d), publicMessage: '%s', serviceMessage: '%s', publicMetaData: %s, serviceMetaData: %s}",
-		status.GetErrorName(e.PublicStatusCode),
-		e.PublicStatusCode,
-		status.GetErrorName(e.ServiceStatusCode),
-		e.ServiceStatusCode,
+		"{publicStatus: %s, serviceStatus: %s, publicMessage: '%s', serviceMessage: '%s', publicMetaData: %s, serviceMetaData: %s}",
+		formatStatus(e.PublicStatusCode),
+		formatStatus(e.ServiceStatusCode),
 		e.PublicMessage,
-		e.ServiceMessage,
+		e.serviceMessageForMode(),
 		formatMetaData(e.PublicMetaData),
-		formatMetaData(e.ServiceMetaData),
+		formatMetaData(e.serviceMetaDataForMode()),
 	)
 }
 
+// Mode selects how much service data Error() reveals.
+type Mode int
+
+const (
+	// Development renders every field in Error(). It is the default.
+	Development Mode = iota
+	// Production masks the service message and service metadata values in
+	// Error(), keeping the metadata keys, for logs that may reach
+	// less-trusted sinks.
+	Production
+)
+
+// mode is the current rendering mode of Error().
+var mode = Development
+
+// SetMode sets the rendering mode of Error().
+func SetMode(m Mode) {
+	mode = m
+}
+
+// serviceMessageForMode returns the service message as Error() renders it
+// in the current mode.
+func (e *Error) serviceMessageForMode() string {
+	if mode != Production || e.ServiceMessage == "" {
+		return e.ServiceMessage
+	}
+	return redactedValue
+}
+
+// serviceMetaDataForMode returns the service metadata as Error() renders it
+// in the current mode.
+func (e *Error) serviceMetaDataForMode() map[string]string {
+	if mode != Production {
+		return e.ServiceMetaData
+	}
+	masked := make(map[string]string, len(e.ServiceMetaData))
+	for key := range e.ServiceMetaData {
+		masked[key] = redactedValue
+	}
+	return masked
+}
+
+// useStatusLabels makes Error() render codes as compact status labels.
+var useStatusLabels = false
+
+// SetStatusLabels switches Error() between rendering status codes as
+// "Name (code)" (the default) and the compact status.GetErrorLabel form
+// "Name(code)" expected by some log consumers.
+func SetStatusLabels(enabled bool) {
+	useStatusLabels = enabled
+}
+
+// PublicString renders the error as a concise single line containing only
+// public data, e.g. "BadRequest (4000): Invalid input in one or more fields."
+func (e *Error) PublicString() string {
+	msg := e.PublicMessage
+	if msg != "" && !strings.ContainsAny(msg[len(msg)-1:], ".!?") {
+		msg += "."
+	}
+	return fmt.Sprintf("%s (%d): %s", status.GetErrorName(e.PublicStatusCode), e.PublicStatusCode, msg)
+}
+
+// Helper function to format a status code as a string
+func formatStatus(code status.StatusCode) string {
+	if useStatusLabels {
+		return status.GetErrorLabel(code)
+	}
+	return fmt.Sprintf("%s (%d)", status.GetErrorName(code), code)
+}
+
 // Helper function to format metadata as a string
 func formatMetaData(metaData map[string]string) string {
 	if len(metaData) == 0 {
@@ -58,3 +200,426 @@ func formatMetaData(metaData map[string]string) string {
 func (e *Error) NeutralizeOverDetailedStatus() {
 	e.PublicStatusCode = status.SuppressOverDetail(e.PublicStatusCode)
 }
+
+// WithPublicMessage sets the public message and returns the receiver.
+// If no service message has been set yet, it is mirrored from the public
+// message so logs never end up with an empty service message.
+func (e *Error) WithPublicMessage(msg string) *Error {
+	e.PublicMessage = msg
+	if e.ServiceMessage == "" {
+		e.ServiceMessage = msg
+	}
+	return e
+}
+
+// genericServerMessage is the public message of server errors.
+var genericServerMessage = "A server error occurred. Please try again later."
+
+// SetGenericServerMessage sets the public message used by server errors that
+// carry no more specific one, such as the fallback branches of the DB mappers
+// and Wrap with a 5xx code.
+func SetGenericServerMessage(msg string) {
+	genericServerMessage = msg
+}
+
+// metaKeyTruncated is the service metadata key flagging a truncated service message.
+const metaKeyTruncated = "truncated"
+
+// maxServiceMessageLen caps service messages built by the mappers; 0 disables it.
+var maxServiceMessageLen = 0
+
+// SetMaxServiceMessageLen caps, in characters, the service messages built by
+// the DB mappers. Longer messages are cut with an ellipsis and flagged with
+// "truncated" in service metadata. Zero, the default, disables truncation.
+func SetMaxServiceMessageLen(n int) {
+	maxServiceMessageLen = n
+}
+
+// truncateServiceMessage applies the SetMaxServiceMessageLen cap to e and
+// returns it.
+func truncateServiceMessage(e *Error) *Error {
+	if e == nil || maxServiceMessageLen <= 0 {
+		return e
+	}
+	runes := []rune(e.ServiceMessage)
+	if len(runes) <= maxServiceMessageLen {
+		return e
+	}
+	e.ServiceMessage = string(runes[:maxServiceMessageLen]) + "..."
+	if e.ServiceMetaData == nil {
+		e.ServiceMetaData = make(map[string]string)
+	}
+	e.ServiceMetaData[metaKeyTruncated] = "true"
+	return e
+}
+
+// defaultPublicMessage returns the public message for a bare code: the
+// generic server message for server errors, status.DefaultMessage otherwise.
+func defaultPublicMessage(code status.StatusCode) string {
+	if status.Group(code) == status.ServerError {
+		return genericServerMessage
+	}
+	return status.DefaultMessage(code)
+}
+
+// Wrap builds an error with the given code around cause. The public message
+// is the generic message for the code; cause only surfaces on the service side.
+func Wrap(code status.StatusCode, cause error) *Error {
+	e := &Error{
+		PublicStatusCode:  code,
+		ServiceStatusCode: code,
+		PublicMessage:     defaultPublicMessage(code),
+		PublicMetaData:    map[string]string{},
+		ServiceMetaData:   map[string]string{},
+		cause:             cause,
+	}
+	if cause != nil {
+		e.ServiceMessage = cause.Error()
+		e.ServiceMetaData["raw_error"] = cause.Error()
+	}
+	return e
+}
+
+// WrapJoin is like Wrap but aggregates several independent causes with
+// errors.Join, so errors.Is and errors.As match against any of them.
+func WrapJoin(code status.StatusCode, causes ...error) *Error {
+	return Wrap(code, errors.Join(causes...))
+}
+
+// Is reports whether target is a bare status.StatusCode equal to the public
+// status code, enabling checks like errors.Is(err, status.NotFoundResource).
+func (e *Error) Is(target error) bool {
+	code, ok := target.(status.StatusCode)
+	return ok && e.PublicStatusCode == code
+}
+
+// FirstError calls funcs in order and returns the first non-nil result
+// without calling the remaining ones. It returns nil if every func does.
+func FirstError(funcs ...func() *Error) *Error {
+	for _, fn := range funcs {
+		if e := fn(); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// Unwrap returns the underlying cause, if any.
+func (e *Error) Unwrap() []error {
+	if e.cause == nil {
+		return nil
+	}
+	return []error{e.cause}
+}
+
+// InheritMeta copies parent's service metadata keys that are not already
+// set on the receiver and returns the receiver. Public metadata is never
+// inherited, so re-wrapping cannot leak an inner error's public details.
+func (e *Error) InheritMeta(parent *Error) *Error {
+	if parent == nil || len(parent.ServiceMetaData) == 0 {
+		return e
+	}
+	if e.ServiceMetaData == nil {
+		e.ServiceMetaData = make(map[string]string, len(parent.ServiceMetaData))
+	}
+	for key, value := range parent.ServiceMetaData {
+		if _, exists := e.ServiceMetaData[key]; !exists {
+			e.ServiceMetaData[key] = value
+		}
+	}
+	return e
+}
+
+// Minimal returns a copy of the error stripped down to its status codes and
+// public message, for transports where every byte counts.
+func (e *Error) Minimal() *Error {
+	return &Error{
+		PublicStatusCode:  e.PublicStatusCode,
+		ServiceStatusCode: e.ServiceStatusCode,
+		PublicMessage:     e.PublicMessage,
+		PublicMetaData:    map[string]string{},
+		ServiceMetaData:   map[string]string{},
+	}
+}
+
+// flattenedServicePrefix prefixes service metadata keys merged by Flatten.
+const flattenedServicePrefix = "service_"
+
+// Flatten returns a copy of the error whose public side also carries the
+// service side: the service status code, the service message appended to the
+// public one, and the service metadata under "service_"-prefixed keys. It is
+// the deliberate opposite of IsPublicSafe and only fit for internal endpoints
+// behind strong authentication.
+func (e *Error) Flatten() *Error {
+	flat := &Error{
+		PublicStatusCode:  e.PublicStatusCode,
+		ServiceStatusCode: e.ServiceStatusCode,
+		PublicMessage:     e.PublicMessage,
+		ServiceMessage:    e.ServiceMessage,
+		PublicMetaData:    make(map[string]string, len(e.PublicMetaData)+len(e.ServiceMetaData)),
+		ServiceMetaData:   maps.Clone(e.ServiceMetaData),
+		cause:             e.cause,
+		fieldErrors:       e.fieldErrors,
+	}
+	if e.ServiceStatusCode != 0 {
+		flat.PublicStatusCode = e.ServiceStatusCode
+	}
+	if e.ServiceMessage != "" && e.ServiceMessage != e.PublicMessage {
+		flat.PublicMessage = fmt.Sprintf("%s: %s", e.PublicMessage, e.ServiceMessage)
+	}
+	maps.Copy(flat.PublicMetaData, e.PublicMetaData)
+	for key, value := range e.ServiceMetaData {
+		flat.PublicMetaData[flattenedServicePrefix+key] = value
+	}
+	return flat
+}
+
+// Internalize returns a ServerError standing in for e, for failures that
+// look like client errors but are really ours, e.g. a 400 from a downstream
+// we sent malformed data to. Publicly it shows publicMessage, or the generic
+// server message when empty, and no metadata. e is not kept as the cause, so
+// errors.Is no longer matches its client code; its codes, public message and
+// service metadata are recorded in service metadata for logs.
+func (e *Error) Internalize(publicMessage string) *Error {
+	if publicMessage == "" {
+		publicMessage = genericServerMessage
+	}
+	serviceMessage := e.ServiceMessage
+	if serviceMessage == "" {
+		serviceMessage = e.PublicMessage
+	}
+
+	internal := &Error{
+		PublicStatusCode:  status.ServerError,
+		ServiceStatusCode: status.ServerError,
+		PublicMessage:     publicMessage,
+		ServiceMessage:    serviceMessage,
+		PublicMetaData: map[string]string{
+			"error_type": InternalServerError.String(),
+		},
+		ServiceMetaData: make(map[string]string, len(e.ServiceMetaData)+3),
+	}
+	maps.Copy(internal.ServiceMetaData, e.ServiceMetaData)
+	internal.ServiceMetaData["original_public_status_code"] = strconv.Itoa(int(e.PublicStatusCode))
+	internal.ServiceMetaData["original_service_status_code"] = strconv.Itoa(int(e.ServiceStatusCode))
+	internal.ServiceMetaData["original_public_message"] = e.PublicMessage
+	return internal
+}
+
+// Category returns the category name of the public status code, e.g.
+// "NotFound" for NotFoundResource.
+func (e *Error) Category() string {
+	return e.PublicStatusCode.Category()
+}
+
+// Title returns a short, human-readable title for the category of the public
+// status code, e.g. "Not Found" for NotFoundResource, while PublicMessage
+// carries the detail.
+func (e *Error) Title() string {
+	name := e.Category()
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// CategoryCode returns the generic base code of the public status code's
+// category, e.g. NotFound for NotFoundResource.
+func (e *Error) CategoryCode() status.StatusCode {
+	return status.Group(e.PublicStatusCode)
+}
+
+// WithServiceStatus sets the service status code, leaving the public one
+// untouched, and returns the receiver.
+func (e *Error) WithServiceStatus(code status.StatusCode) *Error {
+	e.ServiceStatusCode = code
+	return e
+}
+
+// WithStatus sets both the public and the service status code and returns
+// the receiver.
+func (e *Error) WithStatus(public, service status.StatusCode) *Error {
+	e.PublicStatusCode = public
+	e.ServiceStatusCode = service
+	return e
+}
+
+// metaKeyTraceID is the service metadata key holding the trace ID.
+const metaKeyTraceID = "trace_id"
+
+// WithTraceID stamps the error with a trace ID for correlation and returns the receiver.
+func (e *Error) WithTraceID(id string) *Error {
+	if e.ServiceMetaData == nil {
+		e.ServiceMetaData = make(map[string]string)
+	}
+	e.ServiceMetaData[metaKeyTraceID] = id
+	return e
+}
+
+// TraceID returns the trace ID set by WithTraceID, or "" if none.
+func (e *Error) TraceID() string {
+	return e.ServiceMetaData[metaKeyTraceID]
+}
+
+// metaKeyUpstreamCode is the service metadata key holding the upstream status code.
+const metaKeyUpstreamCode = "upstream_status_code"
+
+// WithUpstreamCode records the status code of the downstream failure that
+// caused this error, e.g. the 404 behind a 500, and returns the receiver.
+func (e *Error) WithUpstreamCode(code status.StatusCode) *Error {
+	if e.ServiceMetaData == nil {
+		e.ServiceMetaData = make(map[string]string)
+	}
+	e.ServiceMetaData[metaKeyUpstreamCode] = strconv.Itoa(int(code))
+	return e
+}
+
+// UpstreamCode returns the code set by WithUpstreamCode, or 0 if none.
+func (e *Error) UpstreamCode() status.StatusCode {
+	code, err := strconv.Atoi(e.ServiceMetaData[metaKeyUpstreamCode])
+	if err != nil {
+		return 0
+	}
+	return status.StatusCode(code)
+}
+
+// PublicMetaInt returns the public metadata value under key parsed as an int.
+// It reports false when the key is absent or its value is not an integer.
+func (e *Error) PublicMetaInt(key string) (int, bool) {
+	value, ok := e.PublicMetaData[key]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// PublicMetaBool returns the public metadata value under key parsed as a
+// bool, accepting the forms strconv.ParseBool does. It reports false when the
+// key is absent or its value is not a boolean.
+func (e *Error) PublicMetaBool(key string) (bool, bool) {
+	value, ok := e.PublicMetaData[key]
+	if !ok {
+		return false, false
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, false
+	}
+	return b, true
+}
+
+// WithMetaFromStruct copies v's exported string and numeric fields into
+// service metadata, keyed by field name, and returns the receiver. A
+// `meta:"name"` tag overrides the key and `meta:"-"` skips the field. v may be
+// a struct or a pointer to one; anything else is ignored. Public metadata is
+// never touched.
+func (e *Error) WithMetaFromStruct(v any) *Error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return e
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return e
+	}
+
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key := field.Name
+		if tag := field.Tag.Get("meta"); tag == "-" {
+			continue
+		} else if tag != "" {
+			key = tag
+		}
+
+		var value string
+		fv := rv.Field(i)
+		switch fv.Kind() {
+		case reflect.String:
+			value = fv.String()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			value = strconv.FormatInt(fv.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			value = strconv.FormatUint(fv.Uint(), 10)
+		case reflect.Float32, reflect.Float64:
+			value = strconv.FormatFloat(fv.Float(), 'g', -1, fv.Type().Bits())
+		default:
+			continue
+		}
+
+		if e.ServiceMetaData == nil {
+			e.ServiceMetaData = make(map[string]string)
+		}
+		e.ServiceMetaData[key] = value
+	}
+	return e
+}
+
+// FieldErrors returns the structured per-field failures of a validation
+// error, or nil for other errors.
+func (e *Error) FieldErrors() []*FieldValidationError {
+	return e.fieldErrors
+}
+
+// IsPublicSafe reports whether err can be shown to a client verbatim: it must
+// be an *Error with a client-category (4xx) code whose public output carries
+// no service data. Any other error, including one wrapping an *Error, is
+// considered unsafe since its text embeds the wrapped service message.
+func IsPublicSafe(err error) bool {
+	e, ok := err.(*Error)
+	if !ok || e == nil {
+		return false
+	}
+	if httpStatus := e.HTTPStatus(); httpStatus < 400 || httpStatus >= 500 {
+		return false
+	}
+	return !e.publicContainsServiceData()
+}
+
+// publicContainsServiceData reports whether the public message or metadata
+// embed the service message or a service metadata value. Values shared
+// verbatim by both sides, such as a resource name, are not considered leaks.
+func (e *Error) publicContainsServiceData() bool {
+	public := []string{e.PublicMessage}
+	shared := map[string]bool{e.PublicMessage: true}
+	for _, value := range e.PublicMetaData {
+		public = append(public, value)
+		shared[value] = true
+	}
+
+	service := []string{e.ServiceMessage}
+	for _, value := range e.ServiceMetaData {
+		service = append(service, value)
+	}
+
+	for _, s := range service {
+		if s == "" || shared[s] {
+			continue
+		}
+		for _, p := range public {
+			if strings.Contains(p, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HTTPStatus returns the HTTP status code matching the public status code.
+func (e *Error) HTTPStatus() int {
+	return status.HTTPStatus(e.PublicStatusCode)
+}
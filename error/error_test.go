@@ -1,10 +1,14 @@
 package error_test
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/beka-birhanu/toddler/error"
 	"github.com/beka-birhanu/toddler/status"
+	"github.com/lib/pq"
 )
 
 func TestError_Error(t *testing.T) {
@@ -29,3 +33,416 @@ func TestError_Error(t *testing.T) {
 		t.Errorf("unexpected error string.\nExpected:\n%s\nGot:\n%s", expected, actual)
 	}
 }
+
+func TestSetMode_Production(t *testing.T) {
+	error.SetMode(error.Production)
+	defer error.SetMode(error.Development)
+
+	err := &error.Error{
+		PublicStatusCode:  status.BadRequestMissingField,
+		ServiceStatusCode: status.BadRequestMissingField,
+		PublicMessage:     "Missing required field",
+		ServiceMessage:    "Field 'username' is missing in the payload",
+		PublicMetaData: map[string]string{
+			"field": "username",
+		},
+		ServiceMetaData: map[string]string{
+			"requestId": "abc123",
+		},
+	}
+
+	actual := err.Error()
+
+	if !strings.Contains(actual, "serviceMetaData: {requestId: '[REDACTED]'}") {
+		t.Errorf("expected service metadata values to be masked, got:\n%s", actual)
+	}
+	if strings.Contains(actual, "abc123") {
+		t.Errorf("expected the service metadata value not to appear, got:\n%s", actual)
+	}
+	if !strings.Contains(actual, "serviceMessage: '[REDACTED]'") || strings.Contains(actual, "payload") {
+		t.Errorf("expected the service message to be masked, got:\n%s", actual)
+	}
+	if !strings.Contains(actual, "publicMetaData: {field: 'username'}") {
+		t.Errorf("expected public metadata to be kept, got:\n%s", actual)
+	}
+}
+
+func TestSetStatusLabels(t *testing.T) {
+	error.SetStatusLabels(true)
+	defer error.SetStatusLabels(false)
+
+	err := &error.Error{
+		PublicStatusCode:  status.BadRequestMissingField,
+		ServiceStatusCode: status.BadRequestMissingField,
+	}
+
+	expected := "{publicStatus: BadRequest_MissingField(4001), serviceStatus: BadRequest_MissingField(4001), publicMessage: '', serviceMessage: '', publicMetaData: {}, serviceMetaData: {}}"
+	if actual := err.Error(); actual != expected {
+		t.Errorf("unexpected error string.\nExpected:\n%s\nGot:\n%s", expected, actual)
+	}
+}
+
+func TestError_WithPublicMessage(t *testing.T) {
+	err := (&error.Error{}).WithPublicMessage("Something went wrong")
+	if err.PublicMessage != "Something went wrong" {
+		t.Errorf("unexpected public message: %q", err.PublicMessage)
+	}
+	if err.ServiceMessage != "Something went wrong" {
+		t.Errorf("expected service message to mirror public message, got %q", err.ServiceMessage)
+	}
+
+	err = (&error.Error{ServiceMessage: "user 42 hit rate limit"}).WithPublicMessage("Too many requests")
+	if err.ServiceMessage != "user 42 hit rate limit" {
+		t.Errorf("expected existing service message to be kept, got %q", err.ServiceMessage)
+	}
+}
+
+func TestWrapJoin(t *testing.T) {
+	errA := errors.New("cache unavailable")
+	errB := errors.New("queue unavailable")
+	errC := errors.New("unrelated")
+
+	err := error.WrapJoin(status.ServerErrorServiceCommunication, errA, errB)
+
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Error("expected errors.Is to match every joined cause")
+	}
+	if errors.Is(err, errC) {
+		t.Error("expected errors.Is not to match an unrelated error")
+	}
+	if err.PublicMessage != status.DefaultMessage(status.ServerError) {
+		t.Errorf("unexpected public message: %q", err.PublicMessage)
+	}
+}
+
+func TestError_TraceID(t *testing.T) {
+	err := &error.Error{}
+	if err.TraceID() != "" {
+		t.Errorf("expected no trace ID, got %q", err.TraceID())
+	}
+
+	err.WithTraceID("4bf92f3577b34da6a3ce929d0e0e4736")
+
+	if got := err.TraceID(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("unexpected trace ID: %q", got)
+	}
+	if _, ok := err.PublicMetaData["trace_id"]; ok {
+		t.Error("trace ID must be kept in service metadata")
+	}
+}
+
+func TestError_PublicString(t *testing.T) {
+	err := &error.Error{
+		PublicStatusCode:  status.BadRequest,
+		ServiceStatusCode: status.BadRequestMissingField,
+		PublicMessage:     "Invalid input in one or more fields",
+		ServiceMessage:    "Field 'username' is missing in the payload",
+		PublicMetaData:    map[string]string{"fields": "username"},
+	}
+
+	expected := "BadRequest (4000): Invalid input in one or more fields."
+	if actual := err.PublicString(); actual != expected {
+		t.Errorf("unexpected public string.\nExpected: %s\nGot: %s", expected, actual)
+	}
+
+	err.PublicMessage = "Please try again later."
+	if actual := err.PublicString(); actual != "BadRequest (4000): Please try again later." {
+		t.Errorf("unexpected public string: %s", actual)
+	}
+}
+
+func TestError_IsStatusCode(t *testing.T) {
+	var err builtinError = fmt.Errorf("load user: %w", error.Must(status.NotFoundResource))
+
+	if !errors.Is(err, status.NotFoundResource) {
+		t.Error("expected errors.Is to match the public status code")
+	}
+	if errors.Is(err, status.NotFound) {
+		t.Error("expected errors.Is not to match a different code")
+	}
+	if errors.Is(errors.New("plain"), status.NotFoundResource) {
+		t.Error("expected a plain error not to match a status code")
+	}
+}
+
+func TestError_InheritMeta(t *testing.T) {
+	parent := &error.Error{
+		PublicMetaData:  map[string]string{"resourceName": "order"},
+		ServiceMetaData: map[string]string{"trace_id": "abc", "error_type": "Data not found"},
+	}
+	child := &error.Error{
+		ServiceMetaData: map[string]string{"error_type": "Service communication"},
+	}
+
+	child.InheritMeta(parent)
+
+	if got := child.ServiceMetaData["trace_id"]; got != "abc" {
+		t.Errorf("expected missing key to be inherited, got %q", got)
+	}
+	if got := child.ServiceMetaData["error_type"]; got != "Service communication" {
+		t.Errorf("expected existing key to be kept, got %q", got)
+	}
+	if _, ok := child.PublicMetaData["resourceName"]; ok {
+		t.Error("public metadata must not be inherited")
+	}
+}
+
+func TestIsPublicSafe(t *testing.T) {
+	safe := &error.Error{
+		PublicStatusCode:  status.NotFoundResource,
+		ServiceStatusCode: status.NotFoundResource,
+		PublicMessage:     "Either user does not exist or you don't have access",
+		ServiceMessage:    "No record found for user 42",
+		ServiceMetaData:   map[string]string{"raw_error": "sql: no rows in result set"},
+	}
+	if !error.IsPublicSafe(safe) {
+		t.Error("expected a 4xx error without leaks to be safe")
+	}
+
+	leaky := &error.Error{
+		PublicStatusCode:  status.BadRequest,
+		ServiceStatusCode: status.BadRequest,
+		PublicMessage:     "Invalid input: pq: relation \"users\" does not exist",
+		ServiceMetaData:   map[string]string{"raw_error": "pq: relation \"users\" does not exist"},
+	}
+	if error.IsPublicSafe(leaky) {
+		t.Error("expected an error leaking service data to be unsafe")
+	}
+
+	if error.IsPublicSafe(error.Must(status.ServerError)) {
+		t.Error("expected a 5xx error to be unsafe")
+	}
+	if error.IsPublicSafe(errors.New("plain")) {
+		t.Error("expected a plain error to be unsafe")
+	}
+	if error.IsPublicSafe(fmt.Errorf("query failed: %w", safe)) {
+		t.Error("expected a wrapped *Error to be unsafe")
+	}
+}
+
+func TestFirstError(t *testing.T) {
+	calls := 0
+	want := error.Must(status.NotFoundResource)
+
+	got := error.FirstError(
+		func() *error.Error { calls++; return nil },
+		func() *error.Error { calls++; return want },
+		func() *error.Error { calls++; return error.Must(status.ServerError) },
+	)
+
+	if got != want {
+		t.Errorf("expected the second function's error, got %v", got)
+	}
+	if calls != 2 {
+		t.Errorf("expected evaluation to stop after the first error, got %d calls", calls)
+	}
+	if error.FirstError(func() *error.Error { return nil }) != nil {
+		t.Error("expected nil when no function returns an error")
+	}
+}
+
+func TestError_WithMetaFromStruct(t *testing.T) {
+	type params struct {
+		UserID   string
+		Page     int
+		Ratio    float64
+		Token    string `meta:"-"`
+		Region   string `meta:"region"`
+		Tags     []string
+		internal string
+	}
+	err := error.Must(status.BadRequest).WithMetaFromStruct(&params{
+		UserID:   "u-1",
+		Page:     3,
+		Ratio:    0.5,
+		Token:    "secret",
+		Region:   "eu",
+		Tags:     []string{"a"},
+		internal: "hidden",
+	})
+
+	expected := map[string]string{"UserID": "u-1", "Page": "3", "Ratio": "0.5", "region": "eu"}
+	for key, value := range expected {
+		if got := err.ServiceMetaData[key]; got != value {
+			t.Errorf("service metadata %q: got %q, want %q", key, got, value)
+		}
+	}
+	for _, key := range []string{"Token", "Tags", "internal"} {
+		if _, ok := err.ServiceMetaData[key]; ok {
+			t.Errorf("expected %q to be skipped", key)
+		}
+	}
+	if len(err.PublicMetaData) != 0 {
+		t.Errorf("expected public metadata to be untouched, got %v", err.PublicMetaData)
+	}
+}
+
+func TestError_UpstreamCode(t *testing.T) {
+	err := error.Must(status.ServerError)
+	if got := err.UpstreamCode(); got != 0 {
+		t.Errorf("expected no upstream code, got %d", got)
+	}
+
+	err.WithUpstreamCode(status.NotFoundResource)
+
+	if got := err.UpstreamCode(); got != status.NotFoundResource {
+		t.Errorf("unexpected upstream code: got %d, want %d", got, status.NotFoundResource)
+	}
+	if _, ok := err.PublicMetaData["upstream_status_code"]; ok {
+		t.Error("upstream code must be kept in service metadata")
+	}
+}
+
+func TestSetGenericServerMessage(t *testing.T) {
+	error.SetGenericServerMessage("Something went wrong on our side.")
+	defer error.SetGenericServerMessage("A server error occurred. Please try again later.")
+
+	if got := error.FromDBError(errors.New("disk full"), "user").PublicMessage; got != "Something went wrong on our side." {
+		t.Errorf("unexpected FromDBError fallback message: %q", got)
+	}
+	if got := error.FromDBError(&pq.Error{Code: "42P01"}, "user").PublicMessage; got != "Something went wrong on our side." {
+		t.Errorf("unexpected FromDBError default message: %q", got)
+	}
+	if got := error.Wrap(status.ServerErrorDatabase, errors.New("boom")).PublicMessage; got != "Something went wrong on our side." {
+		t.Errorf("unexpected Wrap message: %q", got)
+	}
+	if got := error.Wrap(status.NotFound, errors.New("boom")).PublicMessage; got != status.DefaultMessage(status.NotFound) {
+		t.Errorf("expected non-server codes to keep their default message, got %q", got)
+	}
+}
+
+func TestError_Flatten(t *testing.T) {
+	err := &error.Error{
+		PublicStatusCode:  status.ServerError,
+		ServiceStatusCode: status.ServerErrorDatabase,
+		PublicMessage:     "A server error occurred",
+		ServiceMessage:    "connection refused",
+		PublicMetaData:    map[string]string{"resourceName": "user"},
+		ServiceMetaData:   map[string]string{"resourceName": "users_table", "raw_error": "dial tcp: connection refused"},
+	}
+
+	flat := err.Flatten()
+
+	if flat.PublicStatusCode != status.ServerErrorDatabase {
+		t.Errorf("expected the service status to be public, got %d", flat.PublicStatusCode)
+	}
+	if flat.PublicMessage != "A server error occurred: connection refused" {
+		t.Errorf("unexpected flattened message: %q", flat.PublicMessage)
+	}
+	expected := map[string]string{
+		"resourceName":         "user",
+		"service_resourceName": "users_table",
+		"service_raw_error":    "dial tcp: connection refused",
+	}
+	for key, value := range expected {
+		if got := flat.PublicMetaData[key]; got != value {
+			t.Errorf("public metadata %q: got %q, want %q", key, got, value)
+		}
+	}
+	if _, ok := err.PublicMetaData["service_raw_error"]; ok {
+		t.Error("expected the original error to be left untouched")
+	}
+}
+
+func TestError_PublicMetaTyped(t *testing.T) {
+	err := &error.Error{PublicMetaData: map[string]string{
+		"retry_after": "30",
+		"partial":     "true",
+		"resource":    "user",
+	}}
+
+	if n, ok := err.PublicMetaInt("retry_after"); !ok || n != 30 {
+		t.Errorf("PublicMetaInt(retry_after) = %d, %v; want 30, true", n, ok)
+	}
+	if _, ok := err.PublicMetaInt("missing"); ok {
+		t.Error("expected an absent key to report false")
+	}
+	if _, ok := err.PublicMetaInt("resource"); ok {
+		t.Error("expected an unparseable int to report false")
+	}
+
+	if b, ok := err.PublicMetaBool("partial"); !ok || !b {
+		t.Errorf("PublicMetaBool(partial) = %v, %v; want true, true", b, ok)
+	}
+	if _, ok := err.PublicMetaBool("missing"); ok {
+		t.Error("expected an absent key to report false")
+	}
+	if _, ok := err.PublicMetaBool("resource"); ok {
+		t.Error("expected an unparseable bool to report false")
+	}
+}
+
+func TestError_Category(t *testing.T) {
+	notFound := error.Must(status.NotFoundResource)
+	if got := notFound.Category(); got != "NotFound" {
+		t.Errorf("unexpected category: %q", got)
+	}
+	if got := notFound.CategoryCode(); got != status.NotFound {
+		t.Errorf("unexpected category code: %d", got)
+	}
+
+	conflict := error.Must(status.ConflictDuplicateData)
+	if got := conflict.Category(); got != "Conflict" {
+		t.Errorf("unexpected category: %q", got)
+	}
+	if got := conflict.CategoryCode(); got != status.Conflict {
+		t.Errorf("unexpected category code: %d", got)
+	}
+}
+
+func TestError_WithStatus(t *testing.T) {
+	err := error.Must(status.BadRequest).WithStatus(status.NotFound, status.NotFoundResource)
+
+	if err.PublicStatusCode != status.NotFound {
+		t.Errorf("unexpected public status: got %d, want %d", err.PublicStatusCode, status.NotFound)
+	}
+	if err.ServiceStatusCode != status.NotFoundResource {
+		t.Errorf("unexpected service status: got %d, want %d", err.ServiceStatusCode, status.NotFoundResource)
+	}
+}
+
+func TestError_Internalize(t *testing.T) {
+	original := &error.Error{
+		PublicStatusCode:  status.BadRequestInvalidFormat,
+		ServiceStatusCode: status.BadRequestInvalidFormat,
+		PublicMessage:     "date must be RFC 3339",
+		ServiceMessage:    "billing rejected field due_date",
+		PublicMetaData:    map[string]string{"field": "due_date"},
+		ServiceMetaData:   map[string]string{"service": "billing"},
+	}
+
+	internal := original.Internalize("")
+
+	if internal.HTTPStatus() != 500 || internal.ServiceStatusCode != status.ServerError {
+		t.Errorf("expected a 500 server error, got %d/%d", internal.HTTPStatus(), internal.ServiceStatusCode)
+	}
+	if internal.PublicMessage != "A server error occurred. Please try again later." {
+		t.Errorf("unexpected public message: %q", internal.PublicMessage)
+	}
+	if _, ok := internal.PublicMetaData["field"]; ok {
+		t.Error("expected original public metadata not to be exposed")
+	}
+	if got := internal.ServiceMetaData["original_public_status_code"]; got != "4004" {
+		t.Errorf("unexpected original code in service metadata: %q", got)
+	}
+	if internal.ServiceMetaData["service"] != "billing" || internal.ServiceMessage != original.ServiceMessage {
+		t.Errorf("expected the original service data to be kept, got %v", internal.ServiceMetaData)
+	}
+	if errors.Is(internal, status.BadRequestInvalidFormat) {
+		t.Error("expected the original client code not to match after internalizing")
+	}
+}
+
+func TestError_Title(t *testing.T) {
+	err := error.Must(status.NotFoundResource).WithPublicMessage("Order 42 does not exist")
+
+	if got := err.Title(); got != "Not Found" {
+		t.Errorf("unexpected title: %q", got)
+	}
+	if err.Title() == err.PublicMessage {
+		t.Error("expected the title to differ from the detail")
+	}
+	if got := error.ExpectationFailed("").Title(); got != "Bad Request" {
+		t.Errorf("unexpected title for an out-of-range code: %q", got)
+	}
+}
@@ -0,0 +1,46 @@
+package error
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	uuidPattern   = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	numberPattern = regexp.MustCompile(`\d+`)
+)
+
+// NormalizeMessage replaces variable parts of a message with placeholders:
+// emails become "<email>", UUIDs "<uuid>" and numeric runs "<n>". Messages
+// differing only by those parts normalize to the same string, which makes
+// it suitable for log grouping.
+func NormalizeMessage(msg string) string {
+	msg = emailPattern.ReplaceAllString(msg, "<email>")
+	msg = uuidPattern.ReplaceAllString(msg, "<uuid>")
+	return numberPattern.ReplaceAllString(msg, "<n>")
+}
+
+// Fingerprint returns a stable hash identifying the kind of error, built from
+// the service status code, the normalized service message and the sorted
+// service metadata keys. Errors such as "user 1 not found" and
+// "user 2 not found" share a fingerprint.
+func (e *Error) Fingerprint() string {
+	keys := make([]string, 0, len(e.ServiceMetaData))
+	for key := range e.ServiceMetaData {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	h.Write([]byte(strconv.Itoa(int(e.ServiceStatusCode))))
+	h.Write([]byte{0})
+	h.Write([]byte(NormalizeMessage(e.ServiceMessage)))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.Join(keys, ",")))
+	return hex.EncodeToString(h.Sum(nil))
+}
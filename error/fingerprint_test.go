@@ -0,0 +1,54 @@
+package error_test
+
+import (
+	"testing"
+
+	"github.com/beka-birhanu/toddler/error"
+	"github.com/beka-birhanu/toddler/status"
+)
+
+func TestError_Fingerprint(t *testing.T) {
+	newErr := func(msg, userID string) *error.Error {
+		return &error.Error{
+			PublicStatusCode:  status.NotFoundResource,
+			ServiceStatusCode: status.NotFoundResource,
+			ServiceMessage:    msg,
+			ServiceMetaData:   map[string]string{"user_id": userID, "error_type": "Data not found"},
+		}
+	}
+
+	a := newErr("user 1 not found", "1")
+	b := newErr("user 2 not found", "2")
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Error("expected errors differing only by an ID to share a fingerprint")
+	}
+
+	c := newErr("user 6eb3d746-1be1-445e-9d76-5aa996754dbd not found", "6eb3d746-1be1-445e-9d76-5aa996754dbd")
+	d := newErr("user 5aa99675-1be1-445e-9d76-6eb3d7464dbd not found", "5aa99675-1be1-445e-9d76-6eb3d7464dbd")
+	if c.Fingerprint() != d.Fingerprint() {
+		t.Error("expected errors differing only by a UUID to share a fingerprint")
+	}
+
+	e := newErr("order 1 not found", "1")
+	if a.Fingerprint() == e.Fingerprint() {
+		t.Error("expected different messages to produce different fingerprints")
+	}
+}
+
+func TestNormalizeMessage(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{"user 42 not found", "user <n> not found"},
+		{"order 6eb3d746-1be1-445e-9d76-5aa996754dbd expired", "order <uuid> expired"},
+		{"invite for jane.doe+test@example.com failed", "invite for <email> failed"},
+		{"retry 3 of 5 for user2@example.org", "retry <n> of <n> for <email>"},
+	}
+
+	for _, tt := range tests {
+		if got := error.NormalizeMessage(tt.msg); got != tt.want {
+			t.Errorf("NormalizeMessage(%q): got %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package error
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beka-birhanu/toddler/status"
+)
+
+const (
+	// headerErrorCode carries the public status code of the error.
+	headerErrorCode = "X-Error-Code"
+
+	// metaKeyAllowMethods is the public metadata key listing allowed HTTP methods.
+	metaKeyAllowMethods = "allowed_methods"
+)
+
+// Format selects how a status code is rendered as text.
+type Format int
+
+const (
+	// FormatNumeric renders the numeric code, e.g. "4040".
+	FormatNumeric Format = iota
+	// FormatName renders the registered name, e.g. "NotFound".
+	FormatName
+)
+
+// headerCodeFormat is the format of the X-Error-Code header.
+var headerCodeFormat = FormatNumeric
+
+// SetHeaderCodeFormat sets how HeaderMap renders the X-Error-Code header.
+// It defaults to FormatNumeric.
+func SetHeaderCodeFormat(format Format) {
+	headerCodeFormat = format
+}
+
+// formatCode renders code in the given format.
+func formatCode(code status.StatusCode, format Format) string {
+	if format == FormatName {
+		return status.GetErrorName(code)
+	}
+	return strconv.Itoa(int(code))
+}
+
+// defaultRetryAfter holds fallback Retry-After delays per HTTP status.
+var defaultRetryAfter = map[int]time.Duration{}
+
+// SetDefaultRetryAfter sets the Retry-After delay HeaderMap emits for
+// errors served with httpStatus that carry no explicit RetryAfter. A
+// non-positive d removes the default. It is not safe for concurrent use
+// with HeaderMap and is meant to be called at startup.
+func SetDefaultRetryAfter(httpStatus int, d time.Duration) {
+	if d <= 0 {
+		delete(defaultRetryAfter, httpStatus)
+		return
+	}
+	defaultRetryAfter[httpStatus] = d
+}
+
+// HeaderMap returns the HTTP response headers that should accompany the error.
+func (e *Error) HeaderMap() map[string]string {
+	headers := map[string]string{
+		headerErrorCode: formatCode(e.PublicStatusCode, headerCodeFormat),
+	}
+	if retryAfter, ok := e.PublicMetaData[metaKeyRetryAfter]; ok {
+		headers["Retry-After"] = retryAfter
+	} else if d, ok := defaultRetryAfter[e.HTTPStatus()]; ok {
+		headers["Retry-After"] = strconv.Itoa(int((d + time.Second - 1) / time.Second))
+	}
+	if allowed, ok := e.PublicMetaData[metaKeyAllowMethods]; ok {
+		headers["Allow"] = allowed
+	}
+	return headers
+}
+
+// WriteHTTP is the standard writer for errors: it sets the headers from
+// HeaderMap, writes the HTTP status and the public JSON body.
+func WriteHTTP(w http.ResponseWriter, e *Error) error {
+	body, err := e.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	for key, value := range e.HeaderMap() {
+		w.Header().Set(key, value)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.HTTPStatus())
+	_, err = w.Write(body)
+	return err
+}
+
+// Handler returns a handler that always responds with e through WriteHTTP,
+// e.g. to mount on routes disabled during an incident or by a feature flag.
+func Handler(e *Error) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		_ = WriteHTTP(w, e)
+	}
+}
+
+// requestHeaderAllowlist lists the request headers WithRequest records.
+var requestHeaderAllowlist = []string{"User-Agent", "X-Request-Id"}
+
+// SetRequestHeaderAllowlist replaces the request headers recorded by
+// WithRequest. Only headers on this list are ever captured.
+func SetRequestHeaderAllowlist(headers ...string) {
+	requestHeaderAllowlist = headers
+}
+
+// WithRequest records the request method, path and allowlisted headers in
+// service metadata, never in public metadata, and returns the receiver.
+func (e *Error) WithRequest(r *http.Request) *Error {
+	if r == nil {
+		return e
+	}
+	if e.ServiceMetaData == nil {
+		e.ServiceMetaData = make(map[string]string)
+	}
+	e.ServiceMetaData["request_method"] = r.Method
+	if r.URL != nil {
+		e.ServiceMetaData["request_path"] = r.URL.Path
+	}
+	for _, header := range requestHeaderAllowlist {
+		if value := r.Header.Get(header); value != "" {
+			e.ServiceMetaData["request_header_"+strings.ToLower(header)] = value
+		}
+	}
+	return e
+}
+
+// maxResponseBodyExcerpt caps the downstream response body FromHTTPResponse
+// inspects and records.
+const maxResponseBodyExcerpt = 4096
+
+// successBodyErrorDetector classifies error objects in 2xx response bodies.
+var successBodyErrorDetector func(body []byte) (status.StatusCode, bool)
+
+// SetSuccessBodyErrorDetector sets a function FromHTTPResponse consults for
+// 2xx responses, for downstreams that report errors with HTTP 200 and an
+// error object in the body. When it reports true, the response is mapped to
+// an error with the returned code. Passing nil disables detection.
+func SetSuccessBodyErrorDetector(detector func(body []byte) (status.StatusCode, bool)) {
+	successBodyErrorDetector = detector
+}
+
+// FromHTTPResponse maps a downstream HTTP response into a structured
+// application error. A failed backend call is our failure, so downstream 4xx
+// and 5xx statuses become ServerErrorServiceCommunication, and a retryable
+// timeout or unavailability for 504 and 503. The downstream meaning (e.g.
+// NotFound for a 404) is recorded via WithUpstreamCode. Responses below 400
+// return nil unless the success body error detector flags them.
+//
+// Up to the first 4KB of the body are read for inspection and recorded in
+// service metadata; resp.Body remains fully readable afterwards.
+func FromHTTPResponse(resp *http.Response) *Error {
+	if resp == nil {
+		return nil
+	}
+
+	var body []byte
+	if resp.Body != nil {
+		body, _ = io.ReadAll(io.LimitReader(resp.Body, maxResponseBodyExcerpt))
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+	}
+
+	code := downstreamStatusCode(resp.StatusCode)
+	errorType := DownstreamError
+	if resp.StatusCode < http.StatusBadRequest {
+		if successBodyErrorDetector == nil {
+			return nil
+		}
+		detected, ok := successBodyErrorDetector(body)
+		if !ok {
+			return nil
+		}
+		code = detected
+		errorType = DownstreamErrorInSuccessBody
+	}
+
+	e := &Error{
+		PublicStatusCode:  code,
+		ServiceStatusCode: code,
+		PublicMessage:     defaultPublicMessage(code),
+		PublicMetaData: map[string]string{
+			"error_type": errorType.String(),
+		},
+		ServiceMessage: fmt.Sprintf("Downstream responded with %s", resp.Status),
+		ServiceMetaData: map[string]string{
+			"error_type":           errorType.String(),
+			"upstream_http_status": strconv.Itoa(resp.StatusCode),
+			"response_body":        string(body),
+		},
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		e.WithUpstreamCode(upstreamStatusCode(resp.StatusCode))
+	}
+	if resp.Request != nil && resp.Request.URL != nil {
+		e.ServiceMetaData["upstream_url"] = resp.Request.URL.Redacted()
+	}
+	if code == status.ServerErrorTimeout || code == status.ServerErrorUnavailable {
+		e.ServiceMetaData[metaKeyRetryable] = "true"
+	}
+	return e
+}
+
+// downstreamStatusCode maps a downstream HTTP status to the code reported
+// for it.
+func downstreamStatusCode(httpStatus int) status.StatusCode {
+	switch httpStatus {
+	case http.StatusServiceUnavailable:
+		return status.ServerErrorUnavailable
+	case http.StatusGatewayTimeout:
+		return status.ServerErrorTimeout
+	default:
+		return status.ServerErrorServiceCommunication
+	}
+}
+
+// upstreamStatusCode maps a downstream HTTP status to the code describing it
+// from the downstream's point of view, recorded via WithUpstreamCode.
+func upstreamStatusCode(httpStatus int) status.StatusCode {
+	switch httpStatus {
+	case http.StatusUnauthorized:
+		return status.Unauthorized
+	case http.StatusForbidden:
+		return status.Forbidden
+	case http.StatusNotFound:
+		return status.NotFound
+	case http.StatusMethodNotAllowed:
+		return status.BadRequestMethodNotAllowed
+	case http.StatusConflict:
+		return status.Conflict
+	case http.StatusLocked:
+		return status.ConflictLocked
+	case http.StatusServiceUnavailable:
+		return status.ServerErrorUnavailable
+	case http.StatusGatewayTimeout:
+		return status.ServerErrorTimeout
+	}
+	switch {
+	case httpStatus >= 400 && httpStatus < 500:
+		return status.BadRequest
+	default:
+		return status.ServerError
+	}
+}
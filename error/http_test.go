@@ -0,0 +1,158 @@
+package error_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beka-birhanu/toddler/error"
+	"github.com/beka-birhanu/toddler/status"
+)
+
+func TestSetDefaultRetryAfter(t *testing.T) {
+	error.SetDefaultRetryAfter(http.StatusServiceUnavailable, 15*time.Second)
+	t.Cleanup(func() { error.SetDefaultRetryAfter(http.StatusServiceUnavailable, 0) })
+
+	err := error.Must(status.ServerErrorUnavailable)
+	if got := err.HeaderMap()["Retry-After"]; got != "15" {
+		t.Errorf("expected default Retry-After for 503, got %q", got)
+	}
+
+	err.WithRetryAfter(5 * time.Second)
+	if got := err.HeaderMap()["Retry-After"]; got != "5" {
+		t.Errorf("expected explicit Retry-After to win, got %q", got)
+	}
+
+	if _, ok := error.Must(status.ServerErrorTimeout).HeaderMap()["Retry-After"]; ok {
+		t.Error("expected no Retry-After for a status without a default")
+	}
+}
+
+func TestError_WithRequest(t *testing.T) {
+	error.SetRequestHeaderAllowlist("X-Request-Id")
+	defer error.SetRequestHeaderAllowlist("User-Agent", "X-Request-Id")
+
+	r := httptest.NewRequest(http.MethodPost, "/users/42?debug=1", nil)
+	r.Header.Set("X-Request-Id", "req-123")
+	r.Header.Set("Authorization", "Bearer secret")
+
+	err := error.Must(status.BadRequest).WithRequest(r)
+
+	expected := map[string]string{
+		"request_method":              "POST",
+		"request_path":                "/users/42",
+		"request_header_x-request-id": "req-123",
+	}
+	for key, want := range expected {
+		if got := err.ServiceMetaData[key]; got != want {
+			t.Errorf("service metadata %q: got %q, want %q", key, got, want)
+		}
+		if _, ok := err.PublicMetaData[key]; ok {
+			t.Errorf("request info %q must not be public", key)
+		}
+	}
+	if _, ok := err.ServiceMetaData["request_header_authorization"]; ok {
+		t.Error("expected headers outside the allowlist to be skipped")
+	}
+}
+
+func TestSetHeaderCodeFormat(t *testing.T) {
+	err := error.Must(status.NotFoundResource)
+
+	if got := err.HeaderMap()["X-Error-Code"]; got != "4041" {
+		t.Errorf("expected numeric code by default, got %q", got)
+	}
+
+	error.SetHeaderCodeFormat(error.FormatName)
+	defer error.SetHeaderCodeFormat(error.FormatNumeric)
+
+	if got := err.HeaderMap()["X-Error-Code"]; got != "NotFound_Resource" {
+		t.Errorf("expected code name, got %q", got)
+	}
+}
+
+func TestFromHTTPResponse(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusNotFound,
+		Status:     "404 Not Found",
+		Body:       io.NopCloser(strings.NewReader(`{"error":"no such user"}`)),
+	}
+
+	err := error.FromHTTPResponse(resp)
+
+	if err.PublicStatusCode != status.ServerErrorServiceCommunication {
+		t.Errorf("expected a downstream 404 not to surface as our own, got %d", err.PublicStatusCode)
+	}
+	if got := err.UpstreamCode(); got != status.NotFound {
+		t.Errorf("expected the upstream code to be kept, got %d", got)
+	}
+	if got := err.ServiceMetaData["response_body"]; got != `{"error":"no such user"}` {
+		t.Errorf("unexpected response_body metadata: %q", got)
+	}
+	if body, _ := io.ReadAll(resp.Body); string(body) != `{"error":"no such user"}` {
+		t.Errorf("expected the body to remain readable, got %q", body)
+	}
+
+	unavailable := error.FromHTTPResponse(&http.Response{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable"})
+	if unavailable.PublicStatusCode != status.ServerErrorUnavailable || !unavailable.IsRetryable() {
+		t.Errorf("expected a retryable unavailable error, got %d", unavailable.PublicStatusCode)
+	}
+
+	ok := &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Body: io.NopCloser(strings.NewReader(`{}`))}
+	if err := error.FromHTTPResponse(ok); err != nil {
+		t.Errorf("expected nil for a 200 response, got %v", err)
+	}
+}
+
+func TestSetSuccessBodyErrorDetector(t *testing.T) {
+	error.SetSuccessBodyErrorDetector(func(body []byte) (status.StatusCode, bool) {
+		if strings.Contains(string(body), `"code":"DUPLICATE"`) {
+			return status.ConflictDuplicateData, true
+		}
+		return 0, false
+	})
+	defer error.SetSuccessBodyErrorDetector(nil)
+
+	flagged := &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Body:       io.NopCloser(strings.NewReader(`{"ok":false,"code":"DUPLICATE"}`)),
+	}
+	err := error.FromHTTPResponse(flagged)
+	if err == nil {
+		t.Fatal("expected the flagged 200 response to map to an error")
+	}
+	if err.PublicStatusCode != status.ConflictDuplicateData {
+		t.Errorf("unexpected public status: %d", err.PublicStatusCode)
+	}
+	if err.HTTPStatus() != http.StatusConflict {
+		t.Errorf("unexpected HTTP status: %d", err.HTTPStatus())
+	}
+
+	clean := &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Body: io.NopCloser(strings.NewReader(`{"ok":true}`))}
+	if err := error.FromHTTPResponse(clean); err != nil {
+		t.Errorf("expected nil for an unflagged 200 response, got %v", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	maintenance := error.Must(status.ServerErrorUnavailable).WithPublicMessage("Down for maintenance")
+	handler := error.Handler(maintenance)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("X-Error-Code"); got != "5004" {
+		t.Errorf("unexpected X-Error-Code header: %q", got)
+	}
+	expected, _ := maintenance.MarshalJSON()
+	if rec.Body.String() != string(expected) {
+		t.Errorf("unexpected body.\nExpected: %s\nGot: %s", expected, rec.Body.String())
+	}
+}
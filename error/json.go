@@ -0,0 +1,242 @@
+package error
+
+import (
+	"encoding/json"
+	"maps"
+	"reflect"
+	"strings"
+
+	"github.com/beka-birhanu/toddler/status"
+)
+
+// publicJSON is the wire shape of an error as seen by clients.
+// Service-side fields are never part of it, and metadata is omitted when empty.
+type publicJSON struct {
+	Code       string            `json:"code,omitempty"`
+	StatusCode status.StatusCode `json:"status_code"`
+	Message    string            `json:"message"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Hint       string            `json:"hint,omitempty"`
+	DocURL     string            `json:"doc_url,omitempty"`
+}
+
+// includeCodeSlug controls whether the public JSON carries the code slug.
+var includeCodeSlug = false
+
+// SetIncludeCodeSlug controls whether MarshalJSON and PublicMap include the
+// machine-readable slug of the public code (see status.Slug) under "code",
+// next to the numeric "status_code". It defaults to false.
+func SetIncludeCodeSlug(enabled bool) {
+	includeCodeSlug = enabled
+}
+
+func (e *Error) publicJSON() publicJSON {
+	var code string
+	if includeCodeSlug {
+		code = status.Slug(e.PublicStatusCode)
+	}
+	return publicJSON{
+		Code:       code,
+		StatusCode: e.PublicStatusCode,
+		Message:    e.PublicMessage,
+		Metadata:   e.PublicMetaData,
+		Hint:       status.ActionHint(e.PublicStatusCode),
+		DocURL:     status.DocURL(e.PublicStatusCode),
+	}
+}
+
+// MarshalJSON implements json.Marshaler, emitting only the public view of the error.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.publicJSON())
+}
+
+// Envelope wraps the public view of e in the standard response envelope:
+// {"success": false, "error": {...}}. A nil e yields {"success": false}.
+func Envelope(e *Error) map[string]any {
+	if e == nil {
+		return map[string]any{"success": false}
+	}
+	return map[string]any{
+		"success": false,
+		"error":   e.PublicMap(),
+	}
+}
+
+// SuccessEnvelope is the happy-path counterpart of Envelope:
+// {"success": true, "data": ...}.
+func SuccessEnvelope(data any) map[string]any {
+	return map[string]any{
+		"success": true,
+		"data":    data,
+	}
+}
+
+// byteCounter is an io.Writer that only counts what is written to it.
+type byteCounter int
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
+
+// PublicJSONSize returns the length in bytes of the output of MarshalJSON.
+// The JSON is streamed into a counter rather than kept in memory.
+func (e *Error) PublicJSONSize() int {
+	var n byteCounter
+	if err := json.NewEncoder(&n).Encode(e.publicJSON()); err != nil {
+		return 0
+	}
+	// Encode terminates the value with a newline that MarshalJSON doesn't emit.
+	return int(n) - 1
+}
+
+// wireServiceExposure controls whether ToWire includes service fields.
+var wireServiceExposure = true
+
+// SetWireServiceExposure controls whether ToWire serializes the service
+// status, message and metadata. It defaults to true; disable it in
+// production to guarantee service data never leaves the process, in which
+// case ToWire produces the public wire format.
+func SetWireServiceExposure(enabled bool) {
+	wireServiceExposure = enabled
+}
+
+// wireJSON is the full shape of an error exchanged between trusted services.
+type wireJSON struct {
+	publicJSON
+	ServiceStatusCode status.StatusCode `json:"service_status_code,omitempty"`
+	ServiceMessage    string            `json:"service_message,omitempty"`
+	ServiceMetadata   map[string]string `json:"service_metadata,omitempty"`
+}
+
+// ToWire serializes the error for transport between trusted services,
+// including service fields unless disabled via SetWireServiceExposure.
+func (e *Error) ToWire() ([]byte, error) {
+	if !wireServiceExposure {
+		return json.Marshal(e.publicJSON())
+	}
+	return json.Marshal(wireJSON{
+		publicJSON:        e.publicJSON(),
+		ServiceStatusCode: e.ServiceStatusCode,
+		ServiceMessage:    e.ServiceMessage,
+		ServiceMetadata:   e.ServiceMetaData,
+	})
+}
+
+// problemJSON is the RFC 9457 (problem+json) shape of an error.
+type problemJSON struct {
+	Type       string            `json:"type"`
+	Title      string            `json:"title"`
+	Status     int               `json:"status"`
+	Detail     string            `json:"detail"`
+	StatusCode status.StatusCode `json:"status_code"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// ProblemJSON renders the public view of the error as an
+// application/problem+json document. The problem type is the code's
+// registered documentation URL, or "about:blank" when none is registered.
+// The title is Title and the detail is the public message.
+func (e *Error) ProblemJSON() ([]byte, error) {
+	problemType := status.DocURL(e.PublicStatusCode)
+	if problemType == "" {
+		problemType = "about:blank"
+	}
+	return json.Marshal(problemJSON{
+		Type:       problemType,
+		Title:      e.Title(),
+		Status:     e.HTTPStatus(),
+		Detail:     e.PublicMessage,
+		StatusCode: e.PublicStatusCode,
+		Metadata:   e.PublicMetaData,
+	})
+}
+
+// OpenAPISchema returns a JSON schema object describing the output of
+// MarshalJSON, suitable for an OpenAPI error response. It is derived from
+// the marshaled type itself so the two cannot drift apart.
+func OpenAPISchema() map[string]any {
+	t := reflect.TypeOf(publicJSON{})
+	properties := make(map[string]any, t.NumField())
+	required := make([]string, 0, t.NumField())
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		properties[name] = schemaForType(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// schemaForType maps a Go type to its JSON schema description.
+func schemaForType(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaForType(t.Elem())}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaForType(t.Elem())}
+	case reflect.Pointer:
+		return schemaForType(t.Elem())
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
+
+// PublicMap returns the public view of the error, with the same keys as
+// MarshalJSON, for serializers that work on plain maps (msgpack, yaml, ...).
+func (e *Error) PublicMap() map[string]any {
+	pub := e.publicJSON()
+	m := map[string]any{
+		"status_code": int(pub.StatusCode),
+		"message":     pub.Message,
+	}
+	if pub.Code != "" {
+		m["code"] = pub.Code
+	}
+	if len(pub.Metadata) > 0 {
+		m["metadata"] = maps.Clone(pub.Metadata)
+	}
+	if pub.Hint != "" {
+		m["hint"] = pub.Hint
+	}
+	if pub.DocURL != "" {
+		m["doc_url"] = pub.DocURL
+	}
+	return m
+}
+
+// GinH returns the public view of the error as a plain map, ready to be
+// passed as gin.H without importing gin:
+//
+//	c.JSON(e.HTTPStatus(), e.GinH())
+func (e *Error) GinH() map[string]any {
+	return e.PublicMap()
+}
+
+// ServiceMap is the service-side counterpart of PublicMap. It must only be
+// handed to internal sinks.
+func (e *Error) ServiceMap() map[string]any {
+	return map[string]any{
+		"status_code": int(e.ServiceStatusCode),
+		"message":     e.ServiceMessage,
+		"metadata":    maps.Clone(e.ServiceMetaData),
+	}
+}
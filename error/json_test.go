@@ -0,0 +1,264 @@
+package error_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/beka-birhanu/toddler/error"
+	"github.com/beka-birhanu/toddler/status"
+)
+
+func TestError_MarshalJSON(t *testing.T) {
+	err := &error.Error{
+		PublicStatusCode:  status.NotFoundResource,
+		ServiceStatusCode: status.NotFoundResource,
+		PublicMessage:     "user not found",
+		ServiceMessage:    "no rows for user 42",
+		PublicMetaData:    map[string]string{"resourceName": "user"},
+		ServiceMetaData:   map[string]string{"raw_error": "sql: no rows in result set"},
+	}
+
+	data, marshalErr := json.Marshal(err)
+	if marshalErr != nil {
+		t.Fatalf("unexpected marshal error: %v", marshalErr)
+	}
+
+	expected := `{"status_code":4041,"message":"user not found","metadata":{"resourceName":"user"},"hint":"Check the identifier and try again."}`
+	if string(data) != expected {
+		t.Errorf("unexpected JSON.\nExpected:\n%s\nGot:\n%s", expected, data)
+	}
+}
+
+func TestOpenAPISchema(t *testing.T) {
+	schema := error.OpenAPISchema()
+
+	if schema["type"] != "object" {
+		t.Errorf("unexpected schema type: %v", schema["type"])
+	}
+
+	properties := schema["properties"].(map[string]any)
+	expected := map[string]string{
+		"code":        "string",
+		"status_code": "integer",
+		"message":     "string",
+		"metadata":    "object",
+		"hint":        "string",
+		"doc_url":     "string",
+	}
+	for name, typ := range expected {
+		prop, ok := properties[name].(map[string]any)
+		if !ok {
+			t.Errorf("expected property %q in schema", name)
+			continue
+		}
+		if prop["type"] != typ {
+			t.Errorf("property %q: expected type %q, got %v", name, typ, prop["type"])
+		}
+	}
+	if len(properties) != len(expected) {
+		t.Errorf("expected %d properties, got %d", len(expected), len(properties))
+	}
+}
+
+func TestError_PublicMapAndServiceMap(t *testing.T) {
+	err := &error.Error{
+		PublicStatusCode:  status.ServerError,
+		ServiceStatusCode: status.ServerErrorDatabase,
+		PublicMessage:     "A server error occurred.",
+		ServiceMessage:    "connection refused",
+		PublicMetaData:    map[string]string{"error_type": "Internal"},
+		ServiceMetaData:   map[string]string{"raw_error": "dial tcp: connection refused"},
+	}
+
+	expectedPublic := map[string]any{
+		"status_code": 5000,
+		"message":     "A server error occurred.",
+		"metadata":    map[string]string{"error_type": "Internal"},
+		"hint":        "Retry later.",
+	}
+	if got := err.PublicMap(); !reflect.DeepEqual(got, expectedPublic) {
+		t.Errorf("unexpected public map.\nExpected: %v\nGot: %v", expectedPublic, got)
+	}
+
+	expectedService := map[string]any{
+		"status_code": 5001,
+		"message":     "connection refused",
+		"metadata":    map[string]string{"raw_error": "dial tcp: connection refused"},
+	}
+	if got := err.ServiceMap(); !reflect.DeepEqual(got, expectedService) {
+		t.Errorf("unexpected service map.\nExpected: %v\nGot: %v", expectedService, got)
+	}
+}
+
+func TestError_Minimal(t *testing.T) {
+	err := &error.Error{
+		PublicStatusCode:  status.BadRequestMissingField,
+		ServiceStatusCode: status.BadRequestMissingField,
+		PublicMessage:     "Missing required field",
+		ServiceMessage:    "Field 'username' is missing in the payload",
+		PublicMetaData:    map[string]string{"field": "username"},
+		ServiceMetaData:   map[string]string{"requestId": "abc123"},
+	}
+
+	minimal := err.Minimal()
+	if minimal.ServiceMessage != "" || len(minimal.PublicMetaData) != 0 || len(minimal.ServiceMetaData) != 0 {
+		t.Errorf("expected metadata and service message to be stripped, got %+v", minimal)
+	}
+	if len(err.PublicMetaData) != 1 {
+		t.Error("expected the original error to be left untouched")
+	}
+
+	data, marshalErr := json.Marshal(minimal)
+	if marshalErr != nil {
+		t.Fatalf("unexpected marshal error: %v", marshalErr)
+	}
+	expected := `{"status_code":4001,"message":"Missing required field","hint":"Check required fields."}`
+	if string(data) != expected {
+		t.Errorf("unexpected JSON.\nExpected:\n%s\nGot:\n%s", expected, data)
+	}
+}
+
+func TestError_MarshalJSON_CodeSlug(t *testing.T) {
+	error.SetIncludeCodeSlug(true)
+	defer error.SetIncludeCodeSlug(false)
+
+	err := &error.Error{PublicStatusCode: status.ConflictDuplicateData, PublicMessage: "email taken"}
+
+	data, _ := json.Marshal(err)
+	expected := `{"code":"conflict.duplicate_data","status_code":4091,"message":"email taken","hint":"Use a different value."}`
+	if string(data) != expected {
+		t.Errorf("unexpected JSON.\nExpected:\n%s\nGot:\n%s", expected, data)
+	}
+	if got := err.PublicMap()["code"]; got != "conflict.duplicate_data" {
+		t.Errorf("expected the slug in the public map, got %v", got)
+	}
+}
+
+func TestError_MarshalJSON_OmitsEmptyHint(t *testing.T) {
+	err := &error.Error{PublicStatusCode: status.StatusCode(1234), PublicMessage: "custom"}
+
+	data, _ := json.Marshal(err)
+	if expected := `{"status_code":1234,"message":"custom"}`; string(data) != expected {
+		t.Errorf("unexpected JSON.\nExpected:\n%s\nGot:\n%s", expected, data)
+	}
+}
+
+func TestError_ProblemJSON(t *testing.T) {
+	err := &error.Error{PublicStatusCode: status.NotFound, PublicMessage: "order not found"}
+
+	data, marshalErr := err.ProblemJSON()
+	if marshalErr != nil {
+		t.Fatalf("unexpected marshal error: %v", marshalErr)
+	}
+	expected := `{"type":"about:blank","title":"Not Found","status":404,"detail":"order not found","status_code":4040}`
+	if string(data) != expected {
+		t.Errorf("unexpected problem JSON.\nExpected:\n%s\nGot:\n%s", expected, data)
+	}
+}
+
+func TestRegisterDocURL(t *testing.T) {
+	const url = "https://docs.example.com/errors/conflict-resource-in-use"
+	status.RegisterDocURL(status.ConflictResourceInUse, url)
+
+	err := &error.Error{PublicStatusCode: status.ConflictResourceInUse, PublicMessage: "still referenced"}
+
+	var body map[string]any
+	data, _ := json.Marshal(err)
+	_ = json.Unmarshal(data, &body)
+	if body["doc_url"] != url {
+		t.Errorf("expected doc_url in JSON, got %v", body["doc_url"])
+	}
+
+	var problem map[string]any
+	data, _ = err.ProblemJSON()
+	_ = json.Unmarshal(data, &problem)
+	if problem["type"] != url {
+		t.Errorf("expected type in problem JSON, got %v", problem["type"])
+	}
+}
+
+func TestError_GinH(t *testing.T) {
+	err := &error.Error{
+		PublicStatusCode:  status.BadRequestMissingField,
+		ServiceStatusCode: status.BadRequestMissingField,
+		PublicMessage:     "Missing required field",
+		ServiceMessage:    "Field 'username' is missing in the payload",
+		PublicMetaData:    map[string]string{"field": "username"},
+	}
+
+	expected := map[string]any{
+		"status_code": 4001,
+		"message":     "Missing required field",
+		"metadata":    map[string]string{"field": "username"},
+		"hint":        "Check required fields.",
+	}
+	if got := err.GinH(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected map.\nExpected: %v\nGot: %v", expected, got)
+	}
+
+	var fromJSON map[string]any
+	data, _ := json.Marshal(err)
+	_ = json.Unmarshal(data, &fromJSON)
+	if len(fromJSON) != len(expected) {
+		t.Errorf("expected GinH to have the same keys as MarshalJSON, got %v", fromJSON)
+	}
+}
+
+func TestError_ToWire(t *testing.T) {
+	err := &error.Error{
+		PublicStatusCode:  status.ServerError,
+		ServiceStatusCode: status.ServerErrorDatabase,
+		PublicMessage:     "A server error occurred.",
+		ServiceMessage:    "connection refused",
+		ServiceMetaData:   map[string]string{"raw_error": "dial tcp: connection refused"},
+	}
+
+	data, _ := err.ToWire()
+	expected := `{"status_code":5000,"message":"A server error occurred.","hint":"Retry later.","service_status_code":5001,"service_message":"connection refused","service_metadata":{"raw_error":"dial tcp: connection refused"}}`
+	if string(data) != expected {
+		t.Errorf("unexpected wire JSON.\nExpected:\n%s\nGot:\n%s", expected, data)
+	}
+
+	error.SetWireServiceExposure(false)
+	defer error.SetWireServiceExposure(true)
+
+	data, _ = err.ToWire()
+	public, _ := json.Marshal(err)
+	if string(data) != string(public) {
+		t.Errorf("expected ToWire to match the public JSON when exposure is disabled.\nExpected:\n%s\nGot:\n%s", public, data)
+	}
+}
+
+func TestError_PublicJSONSize(t *testing.T) {
+	err := &error.Error{
+		PublicStatusCode: status.BadRequestMissingField,
+		PublicMessage:    "Missing required field <name> & more",
+		PublicMetaData:   map[string]string{"field": "username", "hint": "ünïcode"},
+	}
+
+	data, _ := json.Marshal(err)
+	if got := err.PublicJSONSize(); got != len(data) {
+		t.Errorf("expected size %d, got %d", len(data), got)
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	err := &error.Error{PublicStatusCode: status.NotFoundResource, PublicMessage: "user not found"}
+
+	data, _ := json.Marshal(error.Envelope(err))
+	expected := `{"error":{"hint":"Check the identifier and try again.","message":"user not found","status_code":4041},"success":false}`
+	if string(data) != expected {
+		t.Errorf("unexpected envelope.\nExpected:\n%s\nGot:\n%s", expected, data)
+	}
+
+	data, _ = json.Marshal(error.Envelope(nil))
+	if expected := `{"success":false}`; string(data) != expected {
+		t.Errorf("unexpected envelope for nil.\nExpected:\n%s\nGot:\n%s", expected, data)
+	}
+
+	data, _ = json.Marshal(error.SuccessEnvelope(map[string]string{"id": "42"}))
+	if expected := `{"data":{"id":"42"},"success":true}`; string(data) != expected {
+		t.Errorf("unexpected success envelope.\nExpected:\n%s\nGot:\n%s", expected, data)
+	}
+}
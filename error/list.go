@@ -0,0 +1,91 @@
+package error
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/beka-birhanu/toddler/status"
+)
+
+// ErrorList is an ordered collection of errors, e.g. one per item of a bulk
+// request. A nil entry marks an item that succeeded.
+type ErrorList []*Error
+
+// Summary returns the single code describing the whole list: 0 when every
+// item succeeded, MultiStatus when successes and failures are mixed, and
+// otherwise the highest-precedence public code among the failures.
+func (l ErrorList) Summary() status.StatusCode {
+	var codes []status.StatusCode
+	for _, e := range l {
+		if e != nil {
+			codes = append(codes, e.PublicStatusCode)
+		}
+	}
+	switch {
+	case len(codes) == 0:
+		return 0
+	case len(codes) < len(l):
+		return status.MultiStatus
+	default:
+		return overallStatus(codes)
+	}
+}
+
+// WriteHTTP writes the per-item results of a bulk request as
+// {"results": [...]}, each entry a standard envelope (see Envelope), with the
+// HTTP status of Summary: 200 when every item succeeded and 207 Multi-Status
+// when results are mixed.
+func (l ErrorList) WriteHTTP(w http.ResponseWriter) error {
+	results := make([]map[string]any, 0, len(l))
+	for _, e := range l {
+		if e == nil {
+			results = append(results, map[string]any{"success": true})
+		} else {
+			results = append(results, Envelope(e))
+		}
+	}
+	body, err := json.Marshal(map[string]any{"results": results})
+	if err != nil {
+		return err
+	}
+
+	httpStatus := http.StatusOK
+	if code := l.Summary(); code != 0 {
+		httpStatus = status.HTTPStatus(code)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	_, err = w.Write(body)
+	return err
+}
+
+// WriteNDJSON writes each error's public JSON on its own line, flushing w
+// after every entry when it supports flushing, so large lists are streamed
+// rather than buffered. Nil entries (successful items) are skipped.
+func (l ErrorList) WriteNDJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, e := range l {
+		if e == nil {
+			continue
+		}
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+		if err := flush(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flush flushes w if it is buffered, e.g. a *bufio.Writer or an http.ResponseWriter.
+func flush(w io.Writer) error {
+	switch f := w.(type) {
+	case interface{ Flush() error }:
+		return f.Flush()
+	case http.Flusher:
+		f.Flush()
+	}
+	return nil
+}
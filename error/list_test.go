@@ -0,0 +1,105 @@
+package error_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/beka-birhanu/toddler/error"
+	"github.com/beka-birhanu/toddler/status"
+)
+
+func TestErrorList_WriteNDJSON(t *testing.T) {
+	list := error.ErrorList{
+		error.Must(status.BadRequestMissingField),
+		error.Must(status.ConflictDuplicateData),
+		error.Must(status.NotFoundResource),
+	}
+
+	var buf bytes.Buffer
+	if err := list.WriteNDJSON(bufio.NewWriter(&buf)); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+
+	lines := bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n"))
+	if len(lines) != len(list) {
+		t.Fatalf("expected %d lines, got %d", len(list), len(lines))
+	}
+
+	for i, line := range lines {
+		var decoded map[string]any
+		if err := json.Unmarshal(line, &decoded); err != nil {
+			t.Fatalf("line %d is not valid JSON: %v", i, err)
+		}
+		if int(decoded["status_code"].(float64)) != int(list[i].PublicStatusCode) {
+			t.Errorf("line %d: unexpected status_code %v", i, decoded["status_code"])
+		}
+		if _, ok := decoded["service_message"]; ok {
+			t.Errorf("line %d: service data leaked into public JSON", i)
+		}
+	}
+}
+
+func TestErrorList_WriteNDJSON_SkipsNil(t *testing.T) {
+	list := error.ErrorList{nil, error.Must(status.ConflictDuplicateData), nil}
+
+	var buf bytes.Buffer
+	if err := list.WriteNDJSON(&buf); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+
+	lines := bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n"))
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, got %d: %q", len(lines), buf.String())
+	}
+	if bytes.Contains(buf.Bytes(), []byte("null")) {
+		t.Errorf("expected nil entries to be skipped, got %q", buf.String())
+	}
+}
+
+func TestErrorList_Summary(t *testing.T) {
+	mixed := error.ErrorList{nil, error.Must(status.ConflictDuplicateData), nil}
+	if got := mixed.Summary(); got != status.MultiStatus {
+		t.Errorf("expected MultiStatus for mixed results, got %d", got)
+	}
+	if got := status.HTTPStatus(mixed.Summary()); got != http.StatusMultiStatus {
+		t.Errorf("expected MultiStatus to map to 207, got %d", got)
+	}
+
+	if got := (error.ErrorList{nil, nil}).Summary(); got != 0 {
+		t.Errorf("expected 0 when every item succeeded, got %d", got)
+	}
+
+	allFailed := error.ErrorList{error.Must(status.BadRequestMissingField), error.Must(status.NotFoundResource)}
+	if got := allFailed.Summary(); got != status.NotFoundResource {
+		t.Errorf("expected the highest-precedence failure, got %d", got)
+	}
+}
+
+func TestErrorList_WriteHTTP(t *testing.T) {
+	list := error.ErrorList{nil, error.Must(status.ConflictDuplicateData)}
+
+	rec := httptest.NewRecorder()
+	if err := list.WriteHTTP(rec); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+
+	if rec.Code != http.StatusMultiStatus {
+		t.Errorf("expected 207, got %d", rec.Code)
+	}
+	var decoded struct {
+		Results []map[string]any `json:"results"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("body is not valid JSON: %v", err)
+	}
+	if len(decoded.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(decoded.Results))
+	}
+	if decoded.Results[0]["success"] != true || decoded.Results[1]["success"] != false {
+		t.Errorf("unexpected per-item results: %v", decoded.Results)
+	}
+}
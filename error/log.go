@@ -0,0 +1,87 @@
+package error
+
+import (
+	"log/slog"
+	"maps"
+	"sort"
+
+	"github.com/beka-birhanu/toddler/status"
+)
+
+// serviceMetaTransform, when set, rewrites service metadata before it is
+// handed to a logger. It never affects the error itself.
+var serviceMetaTransform func(map[string]string) map[string]string
+
+// SetServiceMetaTransform registers a function applied to a copy of the
+// service metadata by LogFields and LogValue, e.g. to truncate large raw
+// errors in logs. Other representations keep the full metadata.
+// Passing nil removes the transform.
+func SetServiceMetaTransform(fn func(map[string]string) map[string]string) {
+	serviceMetaTransform = fn
+}
+
+// logServiceMetaData returns the service metadata as it should be logged.
+func (e *Error) logServiceMetaData() map[string]string {
+	meta := maps.Clone(e.ServiceMetaData)
+	if serviceMetaTransform != nil {
+		meta = serviceMetaTransform(meta)
+	}
+	return meta
+}
+
+// Severity returns the log level the error should be reported at. It is
+// derived from the service status code so internal reclassification via
+// WithServiceStatus never changes what the client sees.
+func (e *Error) Severity() slog.Level {
+	switch {
+	case e.ServiceStatusCode >= status.ServerError:
+		return slog.LevelError
+	case e.ServiceStatusCode >= status.BadRequest:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// LogFields returns the error as a flat set of structured logging fields.
+func (e *Error) LogFields() map[string]any {
+	return map[string]any{
+		"public_status":       status.GetErrorName(e.PublicStatusCode),
+		"public_status_code":  int(e.PublicStatusCode),
+		"service_status":      status.GetErrorName(e.ServiceStatusCode),
+		"service_status_code": int(e.ServiceStatusCode),
+		"public_message":      e.PublicMessage,
+		"service_message":     e.ServiceMessage,
+		"public_metadata":     e.PublicMetaData,
+		"service_metadata":    e.logServiceMetaData(),
+	}
+}
+
+// LogValue implements slog.LogValuer so an *Error can be logged directly.
+func (e *Error) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("public_status", status.GetErrorName(e.PublicStatusCode)),
+		slog.Int("public_status_code", int(e.PublicStatusCode)),
+		slog.String("service_status", status.GetErrorName(e.ServiceStatusCode)),
+		slog.Int("service_status_code", int(e.ServiceStatusCode)),
+		slog.String("public_message", e.PublicMessage),
+		slog.String("service_message", e.ServiceMessage),
+		slog.Attr{Key: "public_metadata", Value: metaDataGroup(e.PublicMetaData)},
+		slog.Attr{Key: "service_metadata", Value: metaDataGroup(e.logServiceMetaData())},
+	)
+}
+
+// metaDataGroup converts metadata into a slog group with keys in sorted order.
+func metaDataGroup(metaData map[string]string) slog.Value {
+	keys := make([]string, 0, len(metaData))
+	for key := range metaData {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, slog.String(key, metaData[key]))
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,56 @@
+package error_test
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/beka-birhanu/toddler/error"
+	"github.com/beka-birhanu/toddler/status"
+)
+
+func TestSetServiceMetaTransform(t *testing.T) {
+	error.SetServiceMetaTransform(func(meta map[string]string) map[string]string {
+		if raw, ok := meta["raw_error"]; ok && len(raw) > 10 {
+			meta["raw_error"] = raw[:10] + "..."
+		}
+		return meta
+	})
+	defer error.SetServiceMetaTransform(nil)
+
+	rawError := strings.Repeat("x", 50)
+	err := &error.Error{
+		PublicStatusCode:  status.ServerError,
+		ServiceStatusCode: status.ServerErrorDatabase,
+		ServiceMetaData:   map[string]string{"raw_error": rawError},
+	}
+
+	fields := err.LogFields()
+	logged := fields["service_metadata"].(map[string]string)["raw_error"]
+	if logged != strings.Repeat("x", 10)+"..." {
+		t.Errorf("expected raw_error to be truncated in log fields, got %q", logged)
+	}
+
+	if err.ServiceMetaData["raw_error"] != rawError {
+		t.Error("expected the error's own service metadata to be left untouched")
+	}
+	if !strings.Contains(err.Error(), rawError) {
+		t.Error("expected Error() to keep the full raw_error")
+	}
+}
+
+func TestError_WithServiceStatus(t *testing.T) {
+	err := error.Must(status.ServerErrorServiceCommunication)
+	if err.Severity() != slog.LevelError {
+		t.Errorf("expected error severity, got %v", err.Severity())
+	}
+
+	err.WithServiceStatus(status.BadRequest)
+
+	if err.Severity() != slog.LevelWarn {
+		t.Errorf("expected warn severity after downgrade, got %v", err.Severity())
+	}
+	if err.PublicStatusCode != status.ServerErrorServiceCommunication {
+		t.Errorf("expected public status to be unchanged, got %d", err.PublicStatusCode)
+	}
+}
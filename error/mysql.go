@@ -0,0 +1,97 @@
+package error
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/beka-birhanu/toddler/status"
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	mysqlErrDuplicateEntry   = 1062
+	mysqlErrLockWaitTimeout  = 1205
+	mysqlErrDeadlock         = 1213
+	mysqlRetryBackoffSeconds = "1"
+)
+
+// FromMySQLError maps MySQL errors into structured application errors, the
+// same way FromDBError does for PostgreSQL. Errors that are not MySQL driver
+// errors are handed to FromDBError.
+func FromMySQLError(err error, entityName string) *Error {
+	if err == nil {
+		return nil
+	}
+	return truncateServiceMessage(fromMySQLError(err, entityName))
+}
+
+func fromMySQLError(err error, entityName string) *Error {
+	if entityName == "" {
+		entityName = defaultEntityName
+	}
+
+	var myErr *mysql.MySQLError
+	if !errors.As(err, &myErr) {
+		return FromDBError(err, entityName)
+	}
+
+	switch myErr.Number {
+	case mysqlErrDuplicateEntry:
+		return &Error{
+			PublicStatusCode:  status.ConflictDuplicateData,
+			ServiceStatusCode: status.ConflictDuplicateData,
+			PublicMessage:     fmt.Sprintf("A %s with the same value already exists", entityName),
+			PublicMetaData: map[string]string{
+				"error_type":   DataDuplication.String(),
+				"resourceName": entityName,
+			},
+			ServiceMessage:  fmt.Sprintf("Duplicate entry on %s: %s", entityName, myErr.Message),
+			ServiceMetaData: mysqlServiceMetaData(myErr, DataDuplication, entityName),
+		}
+	case mysqlErrLockWaitTimeout, mysqlErrDeadlock:
+		// Transient lock conflicts — retrying the transaction usually succeeds
+		errorType := LockWaitTimeout
+		if myErr.Number == mysqlErrDeadlock {
+			errorType = Deadlock
+		}
+		serviceMeta := mysqlServiceMetaData(myErr, errorType, entityName)
+		serviceMeta[metaKeyRetryable] = "true"
+		return &Error{
+			PublicStatusCode:  status.ServerError,
+			ServiceStatusCode: status.ServerErrorDatabase,
+			PublicMessage:     genericServerMessage,
+			PublicMetaData: map[string]string{
+				"error_type":      InternalDatabaseError.String(),
+				"resourceName":    entityName,
+				metaKeyRetryAfter: mysqlRetryBackoffSeconds,
+			},
+			ServiceMessage:  fmt.Sprintf("%s on %s: %s", errorType, entityName, myErr.Message),
+			ServiceMetaData: serviceMeta,
+		}
+	default:
+		// Unhandled DB errors — treat as server errors
+		return &Error{
+			PublicStatusCode:  status.ServerError,
+			ServiceStatusCode: status.ServerErrorDatabase,
+			PublicMessage:     genericServerMessage,
+			PublicMetaData: map[string]string{
+				"error_type":   InternalDatabaseError.String(),
+				"resourceName": entityName,
+			},
+			ServiceMessage:  fmt.Sprintf("Unhandled MySQL error for %s: %s", entityName, myErr.Message),
+			ServiceMetaData: mysqlServiceMetaData(myErr, InternalDatabaseError, entityName),
+		}
+	}
+}
+
+func mysqlServiceMetaData(myErr *mysql.MySQLError, errorType ErrorTypes, entityName string) map[string]string {
+	return map[string]string{
+		"mysql_errno":   strconv.Itoa(int(myErr.Number)),
+		"sqlstate":      string(myErr.SQLState[:]),
+		"error_type":    errorType.String(),
+		"resourceName":  entityName,
+		"error_message": myErr.Message,
+		"raw_error":     myErr.Error(),
+	}
+}
@@ -0,0 +1,46 @@
+package error_test
+
+import (
+	"testing"
+
+	"github.com/beka-birhanu/toddler/error"
+	"github.com/beka-birhanu/toddler/status"
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestFromMySQLError_Retryable(t *testing.T) {
+	tests := []struct {
+		name  string
+		myErr *mysql.MySQLError
+	}{
+		{"lock wait timeout", &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded; try restarting transaction"}},
+		{"deadlock", &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock; try restarting transaction"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := error.FromMySQLError(tt.myErr, "order")
+
+			if !err.IsRetryable() {
+				t.Error("expected error to be retryable")
+			}
+			if err.ServiceStatusCode != status.ServerErrorDatabase {
+				t.Errorf("unexpected service status: %d", err.ServiceStatusCode)
+			}
+			if err.RetryAfter() == 0 {
+				t.Error("expected a backoff hint")
+			}
+		})
+	}
+}
+
+func TestFromMySQLError_DuplicateEntry(t *testing.T) {
+	err := error.FromMySQLError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a@b.c' for key 'email'"}, "user")
+
+	if err.PublicStatusCode != status.ConflictDuplicateData {
+		t.Errorf("unexpected public status: %d", err.PublicStatusCode)
+	}
+	if err.IsRetryable() {
+		t.Error("expected duplicate entry not to be retryable")
+	}
+}
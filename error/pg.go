@@ -2,10 +2,14 @@ package error
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/beka-birhanu/toddler/status"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/lib/pq"
 )
 
@@ -14,13 +18,235 @@ const (
 	postgresErrForeignKey       = "23503"
 	postgresErrNotNullViolation = "23502"
 	postgresErrCheckViolation   = "23514"
+	postgresErrLockNotAvailable = "55P03"
+	postgresErrQueryCanceled    = "57014"
 )
 
+// defaultEntityName substitutes an empty entity name in the DB mappers.
+var defaultEntityName = "resource"
+
+// SetDefaultEntityName sets the entity name the DB mappers use when none is
+// given or none can be inferred. It defaults to "resource".
+func SetDefaultEntityName(name string) {
+	defaultEntityName = name
+}
+
+// tableEntityResolver translates table names inferred by FromDBErrorAuto
+// into display entity names. Nil leaves table names as they are.
+var tableEntityResolver func(table string) string
+
+// SetTableEntityResolver sets the function FromDBErrorAuto uses to turn an
+// inferred table name into a display entity, e.g. "user_accounts" into
+// "account". When it returns "", the table name is used as is. Pass nil to
+// remove the resolver.
+func SetTableEntityResolver(resolve func(table string) string) {
+	tableEntityResolver = resolve
+}
+
+// sqlStateError is implemented by PostgreSQL driver errors that report a
+// SQLSTATE code. Both *pq.Error and *pgconn.PgError satisfy it.
+type sqlStateError interface {
+	error
+	SQLState() string
+}
+
+// pgError is the driver-agnostic view of a PostgreSQL error the DB mappers
+// switch on, so lib/pq and pgx errors map identically.
+type pgError struct {
+	sqlStateError
+	Message    string
+	Severity   string
+	Detail     string
+	Hint       string
+	Constraint string
+	Table      string
+	Column     string
+}
+
+// asPgError extracts a PostgreSQL error from err's chain, recognizing lib/pq
+// and pgx errors, and any other error exposing a SQLSTATE code.
+func asPgError(err error) (*pgError, bool) {
+	var pgErr *pq.Error
+	if errors.As(err, &pgErr) {
+		return &pgError{
+			sqlStateError: pgErr,
+			Message:       pgErr.Message,
+			Severity:      pgErr.Severity,
+			Detail:        pgErr.Detail,
+			Hint:          pgErr.Hint,
+			Constraint:    pgErr.Constraint,
+			Table:         pgErr.Table,
+			Column:        pgErr.Column,
+		}, true
+	}
+	var pgxErr *pgconn.PgError
+	if errors.As(err, &pgxErr) {
+		return &pgError{
+			sqlStateError: pgxErr,
+			Message:       pgxErr.Message,
+			Severity:      pgxErr.Severity,
+			Detail:        pgxErr.Detail,
+			Hint:          pgxErr.Hint,
+			Constraint:    pgxErr.ConstraintName,
+			Table:         pgxErr.TableName,
+			Column:        pgxErr.ColumnName,
+		}, true
+	}
+	var stateErr sqlStateError
+	if errors.As(err, &stateErr) {
+		return &pgError{sqlStateError: stateErr, Message: stateErr.Error()}, true
+	}
+	return nil, false
+}
+
+// FromDBErrorAuto is like FromDBError but infers the entity name from the
+// PostgreSQL error itself: the reported table, or else the table segment of
+// a default-named constraint (e.g. "user_accounts" for
+// "user_accounts_email_key"), translated by the resolver set with
+// SetTableEntityResolver if any.
+// It falls back to the default entity name when nothing can be inferred.
+func FromDBErrorAuto(err error) *Error {
+	return FromDBError(err, inferDBEntityName(err))
+}
+
+func inferDBEntityName(err error) string {
+	table := inferDBTableName(err)
+	if table == "" {
+		return defaultEntityName
+	}
+	if tableEntityResolver != nil {
+		if entity := tableEntityResolver(table); entity != "" {
+			return entity
+		}
+	}
+	return table
+}
+
+func inferDBTableName(err error) string {
+	pgErr, ok := asPgError(err)
+	if !ok {
+		return ""
+	}
+	if pgErr.Table != "" {
+		return pgErr.Table
+	}
+	return tableFromConstraint(pgErr.Constraint, pgErr.Column)
+}
+
+// constraintSuffixes are the suffixes of PostgreSQL's default constraint
+// names, which follow the pattern "<table>_<columns>_<suffix>".
+var constraintSuffixes = []string{"_pkey", "_key", "_fkey", "_check", "_excl"}
+
+// tableFromConstraint extracts the table name from a default-named
+// constraint, e.g. "user_accounts" from "user_accounts_email_key". column,
+// when known, is stripped as a whole so multi-word column names are handled;
+// otherwise the last segment is assumed to be the column. It returns "" for
+// names that don't follow the default pattern.
+func tableFromConstraint(constraint, column string) string {
+	for _, suffix := range constraintSuffixes {
+		rest, ok := strings.CutSuffix(constraint, suffix)
+		if !ok || rest == "" {
+			continue
+		}
+		if suffix == "_pkey" {
+			return rest
+		}
+		if column != "" {
+			if table, ok := strings.CutSuffix(rest, "_"+column); ok && table != "" {
+				return table
+			}
+		}
+		if i := strings.LastIndex(rest, "_"); i > 0 {
+			return rest[:i]
+		}
+		return ""
+	}
+	return ""
+}
+
+// isReferencedDeletion reports whether a foreign key violation was raised by
+// deleting (or re-keying) a referenced row, rather than by inserting a row
+// with a dangling reference. Postgres words the two cases differently:
+//
+//	update or delete on table "users" violates foreign key constraint ...
+//	insert or update on table "orders" violates foreign key constraint ...
+func isReferencedDeletion(pgErr *pgError) bool {
+	return strings.HasPrefix(pgErr.Message, "update or delete on table")
+}
+
+// FromBulkDBError maps an error from a COPY or other bulk operation like
+// FromDBError, additionally recording the index of the failing row in
+// service metadata. Pass a negative rowHint when the row is unknown.
+func FromBulkDBError(err error, entity string, rowHint int) *Error {
+	e := FromDBError(err, entity)
+	if e == nil {
+		return nil
+	}
+	e.ServiceMetaData["bulk_operation"] = "true"
+	if rowHint >= 0 {
+		e.ServiceMetaData["row_index"] = strconv.Itoa(rowHint)
+	}
+	return e
+}
+
+// ClassifyDBError returns the service status code FromDBError would assign
+// to err, without building the full *Error. It is meant for metrics and
+// quick branching. It returns 0 for a nil error.
+func ClassifyDBError(err error) status.StatusCode {
+	if err == nil {
+		return 0
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return status.NotFoundResource
+	}
+	if errors.Is(err, sql.ErrConnDone) || errors.Is(err, driver.ErrBadConn) {
+		return status.ServerErrorDatabase
+	}
+
+	if pgErr, ok := asPgError(err); ok {
+		code, _ := classifyPg(pgErr)
+		return code
+	}
+	return status.ServerErrorDatabase
+}
+
+// classifyPg returns the service status code and service error type for a
+// PostgreSQL error. SQLSTATEs without a dedicated mapping yield
+// ServerErrorDatabase and an empty error type.
+func classifyPg(pgErr *pgError) (status.StatusCode, ErrorTypes) {
+	switch pgErr.SQLState() {
+	case postgresErrUniqueViolation:
+		return status.ConflictDuplicateData, DataDuplication
+	case postgresErrForeignKey:
+		if isReferencedDeletion(pgErr) {
+			return status.ConflictResourceInUse, ResourceInUse
+		}
+		return status.BadRequest, ForeignKeyViolation
+	case postgresErrNotNullViolation:
+		return status.BadRequest, MissingField
+	case postgresErrCheckViolation:
+		return status.BadRequest, ConstraintCheckFailed
+	case postgresErrLockNotAvailable:
+		return status.Conflict, LockContention
+	case postgresErrQueryCanceled:
+		return status.ServerErrorTimeout, QueryCanceled
+	}
+	return status.ServerErrorDatabase, ""
+}
+
 // FromDBError maps database-level errors into structured application errors.
+// The service message is capped as configured by SetMaxServiceMessageLen.
 func FromDBError(err error, entityName string) *Error {
 	if err == nil {
 		return nil
 	}
+	return truncateServiceMessage(fromDBError(err, entityName))
+}
+
+func fromDBError(err error, entityName string) *Error {
+	if entityName == "" {
+		entityName = defaultEntityName
+	}
 
 	if errors.Is(err, sql.ErrNoRows) {
 		return &Error{
@@ -28,118 +254,216 @@ func FromDBError(err error, entityName string) *Error {
 			ServiceStatusCode: status.NotFoundResource,
 			PublicMessage:     fmt.Sprintf("Either %s does not exist or you don't have access", entityName),
 			PublicMetaData: map[string]string{
-				"error_type":   "Data not found",
+				"error_type":   DataNotFound.String(),
 				"resourceName": entityName,
 			},
 			ServiceMessage: fmt.Sprintf("No record found for %s: %s", entityName, err),
 			ServiceMetaData: map[string]string{
-				"error_type":   "Data not found",
+				"error_type":   DataNotFound.String(),
 				"resourceName": entityName,
 				"raw_error":    err.Error(),
 			},
 		}
 	}
 
-	var pqErr *pq.Error
-	if errors.As(err, &pqErr) {
-		switch pqErr.Code {
-		case postgresErrUniqueViolation:
+	if errors.Is(err, sql.ErrConnDone) || errors.Is(err, driver.ErrBadConn) {
+		// Connection pool hiccup — a fresh connection will likely succeed
+		return &Error{
+			PublicStatusCode:  status.ServerError,
+			ServiceStatusCode: status.ServerErrorDatabase,
+			PublicMessage:     genericServerMessage,
+			PublicMetaData: map[string]string{
+				"error_type":   InternalDatabaseError.String(),
+				"resourceName": entityName,
+			},
+			ServiceMessage: fmt.Sprintf("Database connection failure for %s: %s", entityName, err),
+			ServiceMetaData: map[string]string{
+				"error_type":     DatabaseConnection.String(),
+				"resourceName":   entityName,
+				"raw_error":      err.Error(),
+				metaKeyRetryable: "true",
+			},
+		}
+	}
+
+	if pgErr, ok := asPgError(err); ok {
+		code, errorType := classifyPg(pgErr)
+		switch errorType {
+		case DataDuplication:
 			return &Error{
-				PublicStatusCode:  status.ConflictDuplicateData,
-				ServiceStatusCode: status.ConflictDuplicateData,
+				PublicStatusCode:  code,
+				ServiceStatusCode: code,
 				PublicMessage:     fmt.Sprintf("A %s with the same value already exists", entityName),
 				PublicMetaData: map[string]string{
-					"error_type":   "Data duplication",
+					"error_type":   DataDuplication.String(),
 					"resourceName": entityName,
 				},
-				ServiceMessage: fmt.Sprintf("Unique constraint violation on %s: %s", entityName, pqErr.Message),
+				ServiceMessage: fmt.Sprintf("Unique constraint violation on %s: %s", entityName, pgErr.Message),
 				ServiceMetaData: map[string]string{
-					"pgcode":         string(pqErr.Code),
-					"constraint":     pqErr.Constraint,
-					"error_type":     "Data duplication",
+					"pgcode":         pgErr.SQLState(),
+					"constraint":     pgErr.Constraint,
+					"error_type":     DataDuplication.String(),
 					"resourceName":   entityName,
-					"error_message":  pqErr.Message,
-					"error_severity": pqErr.Severity,
-					"raw_error":      pqErr.Error(),
+					"error_message":  pgErr.Message,
+					"error_severity": pgErr.Severity,
+					"error_detail":   pgErr.Detail,
+					"error_hint":     pgErr.Hint,
+					"raw_error":      pgErr.Error(),
 				},
 			}
-		case postgresErrForeignKey:
+		case ResourceInUse:
 			return &Error{
-				PublicStatusCode:  status.BadRequest,
-				ServiceStatusCode: status.BadRequest,
+				PublicStatusCode:  code,
+				ServiceStatusCode: code,
+				PublicMessage:     fmt.Sprintf("%s is still referenced by other data and cannot be removed", entityName),
+				PublicMetaData: map[string]string{
+					"error_type":   ResourceInUse.String(),
+					"resourceName": entityName,
+				},
+				ServiceMessage: fmt.Sprintf("Foreign key restricts modification of %s: %s", entityName, pgErr.Message),
+				ServiceMetaData: map[string]string{
+					"pgcode":         pgErr.SQLState(),
+					"constraint":     pgErr.Constraint,
+					"error_type":     ResourceInUse.String(),
+					"resourceName":   entityName,
+					"error_message":  pgErr.Message,
+					"error_severity": pgErr.Severity,
+					"error_detail":   pgErr.Detail,
+					"error_hint":     pgErr.Hint,
+					"raw_error":      pgErr.Error(),
+				},
+			}
+		case ForeignKeyViolation:
+			return &Error{
+				PublicStatusCode:  code,
+				ServiceStatusCode: code,
 				PublicMessage:     fmt.Sprintf("%s has invalid reference to related data", entityName),
 				PublicMetaData: map[string]string{
-					"error_type":   "Foreign key violation",
+					"error_type":   ForeignKeyViolation.String(),
 					"resourceName": entityName,
 				},
-				ServiceMessage: fmt.Sprintf("Foreign key constraint failed on %s: %s", entityName, pqErr.Message),
+				ServiceMessage: fmt.Sprintf("Foreign key constraint failed on %s: %s", entityName, pgErr.Message),
 				ServiceMetaData: map[string]string{
-					"pgcode":         string(pqErr.Code),
-					"constraint":     pqErr.Constraint,
-					"error_type":     "Foreign key violation",
+					"pgcode":         pgErr.SQLState(),
+					"constraint":     pgErr.Constraint,
+					"error_type":     ForeignKeyViolation.String(),
 					"resourceName":   entityName,
-					"error_message":  pqErr.Message,
-					"error_severity": pqErr.Severity,
-					"raw_error":      pqErr.Error(),
+					"error_message":  pgErr.Message,
+					"error_severity": pgErr.Severity,
+					"error_detail":   pgErr.Detail,
+					"error_hint":     pgErr.Hint,
+					"raw_error":      pgErr.Error(),
 				},
 			}
-		case postgresErrNotNullViolation:
+		case MissingField:
 			return &Error{
-				PublicStatusCode:  status.BadRequest,
-				ServiceStatusCode: status.BadRequest,
+				PublicStatusCode:  code,
+				ServiceStatusCode: code,
 				PublicMessage:     fmt.Sprintf("%s is missing required fields", entityName),
 				PublicMetaData: map[string]string{
-					"error_type":   "Missing field",
+					"error_type":   MissingField.String(),
 					"resourceName": entityName,
 				},
-				ServiceMessage: fmt.Sprintf("NOT NULL constraint failed on %s: %s", entityName, pqErr.Message),
+				ServiceMessage: fmt.Sprintf("NOT NULL constraint failed on %s: %s", entityName, pgErr.Message),
 				ServiceMetaData: map[string]string{
-					"pgcode":         string(pqErr.Code),
-					"column":         pqErr.Column,
-					"error_type":     "Missing field",
+					"pgcode":         pgErr.SQLState(),
+					"column":         pgErr.Column,
+					"error_type":     MissingField.String(),
 					"resourceName":   entityName,
-					"error_message":  pqErr.Message,
-					"error_severity": pqErr.Severity,
-					"raw_error":      pqErr.Error(),
+					"error_message":  pgErr.Message,
+					"error_severity": pgErr.Severity,
+					"error_detail":   pgErr.Detail,
+					"error_hint":     pgErr.Hint,
+					"raw_error":      pgErr.Error(),
 				},
 			}
-		case postgresErrCheckViolation:
+		case ConstraintCheckFailed:
 			return &Error{
-				PublicStatusCode:  status.BadRequest,
-				ServiceStatusCode: status.BadRequest,
+				PublicStatusCode:  code,
+				ServiceStatusCode: code,
 				PublicMessage:     fmt.Sprintf("%s failed validation rules", entityName),
 				PublicMetaData: map[string]string{
-					"error_type":   "Constraint check failed",
+					"error_type":   ConstraintCheckFailed.String(),
+					"resourceName": entityName,
+				},
+				ServiceMessage: fmt.Sprintf("CHECK constraint violation on %s: %s", entityName, pgErr.Message),
+				ServiceMetaData: map[string]string{
+					"pgcode":         pgErr.SQLState(),
+					"constraint":     pgErr.Constraint,
+					"error_type":     ConstraintCheckFailed.String(),
+					"resourceName":   entityName,
+					"error_message":  pgErr.Message,
+					"error_severity": pgErr.Severity,
+					"error_detail":   pgErr.Detail,
+					"error_hint":     pgErr.Hint,
+					"raw_error":      pgErr.Error(),
+				},
+			}
+		case LockContention:
+			// Row is locked by another transaction (e.g. FOR UPDATE NOWAIT) — safe to retry
+			return &Error{
+				PublicStatusCode:  code,
+				ServiceStatusCode: code,
+				PublicMessage:     fmt.Sprintf("%s is currently being modified by another request. Please try again.", entityName),
+				PublicMetaData: map[string]string{
+					"error_type":   LockContention.String(),
+					"resourceName": entityName,
+				},
+				ServiceMessage: fmt.Sprintf("Lock not available on %s: %s", entityName, pgErr.Message),
+				ServiceMetaData: map[string]string{
+					"pgcode":         pgErr.SQLState(),
+					"error_type":     LockContention.String(),
+					"resourceName":   entityName,
+					"error_message":  pgErr.Message,
+					"error_severity": pgErr.Severity,
+					"error_detail":   pgErr.Detail,
+					"error_hint":     pgErr.Hint,
+					"raw_error":      pgErr.Error(),
+					metaKeyRetryable: "true",
+				},
+			}
+		case QueryCanceled:
+			// Statement timeout or cancellation — the query may succeed on retry
+			return &Error{
+				PublicStatusCode:  code,
+				ServiceStatusCode: code,
+				PublicMessage:     "The request took too long to complete. Please try again later.",
+				PublicMetaData: map[string]string{
+					"error_type":   Timeout.String(),
 					"resourceName": entityName,
 				},
-				ServiceMessage: fmt.Sprintf("CHECK constraint violation on %s: %s", entityName, pqErr.Message),
+				ServiceMessage: fmt.Sprintf("Query canceled on %s: %s", entityName, pgErr.Message),
 				ServiceMetaData: map[string]string{
-					"pgcode":         string(pqErr.Code),
-					"constraint":     pqErr.Constraint,
-					"error_type":     "Constraint check failed",
+					"pgcode":         pgErr.SQLState(),
+					"error_type":     QueryCanceled.String(),
 					"resourceName":   entityName,
-					"error_message":  pqErr.Message,
-					"error_severity": pqErr.Severity,
-					"raw_error":      pqErr.Error(),
+					"error_message":  pgErr.Message,
+					"error_severity": pgErr.Severity,
+					"error_detail":   pgErr.Detail,
+					"error_hint":     pgErr.Hint,
+					"raw_error":      pgErr.Error(),
+					metaKeyRetryable: "true",
 				},
 			}
 		default:
 			// Unhandled DB errors — treat as server errors
 			return &Error{
 				PublicStatusCode:  status.ServerError,
-				ServiceStatusCode: status.ServerErrorDatabase,
-				PublicMessage:     "A server error occurred. Please try again later.",
+				ServiceStatusCode: code,
+				PublicMessage:     genericServerMessage,
 				PublicMetaData: map[string]string{
-					"error_type":   "Internal database error",
+					"error_type":   InternalDatabaseError.String(),
 					"resourceName": entityName,
 				},
-				ServiceMessage: fmt.Sprintf("Unhandled PostgreSQL error for %s: %s", entityName, pqErr.Message),
+				ServiceMessage: fmt.Sprintf("Unhandled PostgreSQL error for %s: %s", entityName, pgErr.Message),
 				ServiceMetaData: map[string]string{
-					"pgcode":         string(pqErr.Code),
+					"pgcode":         pgErr.SQLState(),
 					"resourceName":   entityName,
-					"error_message":  pqErr.Message,
-					"error_severity": pqErr.Severity,
-					"raw_error":      pqErr.Error(),
+					"error_message":  pgErr.Message,
+					"error_severity": pgErr.Severity,
+					"error_detail":   pgErr.Detail,
+					"error_hint":     pgErr.Hint,
+					"raw_error":      pgErr.Error(),
 				},
 			}
 		}
@@ -149,14 +473,14 @@ func FromDBError(err error, entityName string) *Error {
 	return &Error{
 		PublicStatusCode:  status.ServerError,
 		ServiceStatusCode: status.ServerErrorDatabase,
-		PublicMessage:     "A server error occurred. Please try again later.",
+		PublicMessage:     genericServerMessage,
 		PublicMetaData: map[string]string{
-			"error_type":   "Unknown server error",
+			"error_type":   UnknownServerError.String(),
 			"resourceName": entityName,
 		},
 		ServiceMessage: fmt.Sprintf("Unexpected DB error for %s: %s", entityName, err),
 		ServiceMetaData: map[string]string{
-			"error_type":   "Unknown database error",
+			"error_type":   UnknownDatabaseError.String(),
 			"resourceName": entityName,
 			"raw_error":    err.Error(),
 		},
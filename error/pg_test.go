@@ -0,0 +1,335 @@
+package error_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/beka-birhanu/toddler/error"
+	"github.com/beka-birhanu/toddler/status"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+)
+
+func TestFromDBError_LockNotAvailable(t *testing.T) {
+	pqErr := &pq.Error{Code: "55P03", Message: `could not obtain lock on row in relation "orders"`, Severity: "ERROR"}
+
+	err := error.FromDBError(pqErr, "order")
+
+	if err.PublicStatusCode != status.Conflict {
+		t.Errorf("unexpected public status: got %d, want %d", err.PublicStatusCode, status.Conflict)
+	}
+	if !err.IsRetryable() {
+		t.Error("expected lock contention error to be retryable")
+	}
+	if got := err.ServiceMetaData["pgcode"]; got != "55P03" {
+		t.Errorf("unexpected pgcode metadata: %q", got)
+	}
+	if got := err.PublicMetaData["error_type"]; got != "Lock contention" {
+		t.Errorf("unexpected error_type metadata: %q", got)
+	}
+}
+
+func TestFromDBErrorAuto(t *testing.T) {
+	pqErr := &pq.Error{Code: "23505", Constraint: "users_email_key", Message: "duplicate key value violates unique constraint"}
+
+	err := error.FromDBErrorAuto(pqErr)
+
+	if got := err.PublicMetaData["resourceName"]; got != "users" {
+		t.Errorf("expected inferred entity 'users', got %q", got)
+	}
+	if got := err.ServiceMetaData["resourceName"]; got != "users" {
+		t.Errorf("expected inferred entity 'users' in service metadata, got %q", got)
+	}
+
+	err = error.FromDBErrorAuto(&pq.Error{Code: "23505"})
+	if got := err.PublicMetaData["resourceName"]; got != "resource" {
+		t.Errorf("expected fallback entity 'resource', got %q", got)
+	}
+
+	constraints := map[string]string{
+		"user_accounts_email_key":          "user_accounts",
+		"user_accounts_pkey":               "user_accounts",
+		"order_items_quantity_check":       "order_items",
+		"user_accounts_custom_unique_name": "resource",
+	}
+	for constraint, want := range constraints {
+		err := error.FromDBErrorAuto(&pq.Error{Code: "23505", Constraint: constraint})
+		if got := err.PublicMetaData["resourceName"]; got != want {
+			t.Errorf("%s: expected entity %q, got %q", constraint, want, got)
+		}
+	}
+
+	withColumn := error.FromDBErrorAuto(&pq.Error{Code: "23503", Constraint: "order_items_order_id_fkey", Column: "order_id"})
+	if got := withColumn.PublicMetaData["resourceName"]; got != "order_items" {
+		t.Errorf("expected the known column to be stripped, got %q", got)
+	}
+}
+
+func TestSetTableEntityResolver(t *testing.T) {
+	error.SetTableEntityResolver(func(table string) string {
+		if table == "user_accounts" {
+			return "account"
+		}
+		return ""
+	})
+	defer error.SetTableEntityResolver(nil)
+
+	err := error.FromDBErrorAuto(&pq.Error{Code: "23505", Table: "user_accounts"})
+	if got := err.PublicMetaData["resourceName"]; got != "account" {
+		t.Errorf("expected resolved entity 'account', got %q", got)
+	}
+
+	err = error.FromDBErrorAuto(&pq.Error{Code: "23505", Constraint: "user_accounts_email_key"})
+	if got := err.PublicMetaData["resourceName"]; got != "account" {
+		t.Errorf("expected entity resolved from the constraint's table, got %q", got)
+	}
+
+	err = error.FromDBErrorAuto(&pq.Error{Code: "23505", Table: "orders"})
+	if got := err.PublicMetaData["resourceName"]; got != "orders" {
+		t.Errorf("expected unresolved table name 'orders', got %q", got)
+	}
+}
+
+func TestFromDBError_QueryCanceled(t *testing.T) {
+	pqErr := &pq.Error{Code: "57014", Message: "canceling statement due to statement timeout", Severity: "ERROR"}
+
+	err := error.FromDBError(pqErr, "report")
+
+	if err.PublicStatusCode != status.ServerErrorTimeout {
+		t.Errorf("unexpected public status: got %d, want %d", err.PublicStatusCode, status.ServerErrorTimeout)
+	}
+	if !err.IsRetryable() {
+		t.Error("expected query timeout to be retryable")
+	}
+	if got := err.ServiceMetaData["pgcode"]; got != "57014" {
+		t.Errorf("unexpected pgcode metadata: %q", got)
+	}
+}
+
+func TestSetDefaultEntityName(t *testing.T) {
+	err := error.FromDBError(sql.ErrNoRows, "")
+	if got := err.PublicMetaData["resourceName"]; got != "resource" {
+		t.Errorf("expected built-in default entity name, got %q", got)
+	}
+
+	error.SetDefaultEntityName("record")
+	defer error.SetDefaultEntityName("resource")
+
+	err = error.FromDBError(sql.ErrNoRows, "")
+	if got := err.PublicMetaData["resourceName"]; got != "record" {
+		t.Errorf("expected configured default entity name, got %q", got)
+	}
+	if err.PublicMessage != "Either record does not exist or you don't have access" {
+		t.Errorf("unexpected public message: %q", err.PublicMessage)
+	}
+}
+
+func TestFromDBError_DetailAndHint(t *testing.T) {
+	pqErr := &pq.Error{
+		Code:    "23505",
+		Message: "duplicate key value violates unique constraint \"users_email_key\"",
+		Detail:  "Key (email)=(a@b.c) already exists.",
+		Hint:    "Use a different email.",
+	}
+
+	err := error.FromDBError(pqErr, "user")
+
+	if got := err.ServiceMetaData["error_detail"]; got != pqErr.Detail {
+		t.Errorf("unexpected error_detail: %q", got)
+	}
+	if got := err.ServiceMetaData["error_hint"]; got != pqErr.Hint {
+		t.Errorf("unexpected error_hint: %q", got)
+	}
+	for key, value := range err.PublicMetaData {
+		if value == pqErr.Detail || value == pqErr.Hint {
+			t.Errorf("public metadata %q leaks pg detail/hint", key)
+		}
+	}
+}
+
+func TestFromDBError_ConnectionErrors(t *testing.T) {
+	causes := []builtinError{
+		fmt.Errorf("query users: %w", sql.ErrConnDone),
+		fmt.Errorf("query users: %w", driver.ErrBadConn),
+	}
+	for _, cause := range causes {
+		err := error.FromDBError(cause, "user")
+
+		if err.ServiceStatusCode != status.ServerErrorDatabase {
+			t.Errorf("%v: unexpected service status %d", cause, err.ServiceStatusCode)
+		}
+		if !err.IsRetryable() {
+			t.Errorf("%v: expected connection error to be retryable", cause)
+		}
+	}
+}
+
+func TestFromDBError_ForeignKeyDirection(t *testing.T) {
+	onInsert := &pq.Error{
+		Code:    "23503",
+		Message: `insert or update on table "orders" violates foreign key constraint "orders_user_id_fkey"`,
+	}
+	if got := error.FromDBError(onInsert, "order").PublicStatusCode; got != status.BadRequest {
+		t.Errorf("insert: expected BadRequest, got %d", got)
+	}
+
+	onDelete := &pq.Error{
+		Code:    "23503",
+		Message: `update or delete on table "users" violates foreign key constraint "orders_user_id_fkey" on table "orders"`,
+	}
+	err := error.FromDBError(onDelete, "user")
+	if err.PublicStatusCode != status.ConflictResourceInUse {
+		t.Errorf("delete: expected ConflictResourceInUse, got %d", err.PublicStatusCode)
+	}
+	if err.HTTPStatus() != 409 {
+		t.Errorf("delete: expected HTTP 409, got %d", err.HTTPStatus())
+	}
+}
+
+func TestClassifyDBError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  builtinError
+		want status.StatusCode
+	}{
+		{"no rows", sql.ErrNoRows, status.NotFoundResource},
+		{"unique", &pq.Error{Code: "23505"}, status.ConflictDuplicateData},
+		{"foreign key", &pq.Error{Code: "23503", Message: "insert or update on table"}, status.BadRequest},
+		{"foreign key delete", &pq.Error{Code: "23503", Message: "update or delete on table"}, status.ConflictResourceInUse},
+		{"not null", &pq.Error{Code: "23502"}, status.BadRequest},
+		{"check", &pq.Error{Code: "23514"}, status.BadRequest},
+		{"lock not available", &pq.Error{Code: "55P03"}, status.Conflict},
+		{"query canceled", &pq.Error{Code: "57014"}, status.ServerErrorTimeout},
+		{"unhandled pg code", &pq.Error{Code: "42P01"}, status.ServerErrorDatabase},
+		{"bad conn", driver.ErrBadConn, status.ServerErrorDatabase},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := error.ClassifyDBError(tt.err); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+			if got := error.FromDBError(tt.err, "user").ServiceStatusCode; got != tt.want {
+				t.Errorf("FromDBError disagrees: got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromBulkDBError(t *testing.T) {
+	pqErr := &pq.Error{Code: "23505", Message: "duplicate key value violates unique constraint"}
+
+	err := error.FromBulkDBError(pqErr, "product", 17)
+
+	if err.PublicStatusCode != status.ConflictDuplicateData {
+		t.Errorf("unexpected public status: %d", err.PublicStatusCode)
+	}
+	if got := err.ServiceMetaData["row_index"]; got != "17" {
+		t.Errorf("unexpected row_index: %q", got)
+	}
+	if _, ok := err.PublicMetaData["row_index"]; ok {
+		t.Error("row index must stay in service metadata")
+	}
+
+	if _, ok := error.FromBulkDBError(pqErr, "product", -1).ServiceMetaData["row_index"]; ok {
+		t.Error("expected no row_index when the row is unknown")
+	}
+}
+
+func TestFromDBError_PgxErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        *pgconn.PgError
+		wantStatus status.StatusCode
+		wantType   string
+		metaKey    string
+		metaValue  string
+	}{
+		{
+			name:       "unique",
+			err:        &pgconn.PgError{Code: "23505", Message: "duplicate key value violates unique constraint", ConstraintName: "users_email_key"},
+			wantStatus: status.ConflictDuplicateData,
+			wantType:   "Data duplication",
+			metaKey:    "constraint",
+			metaValue:  "users_email_key",
+		},
+		{
+			name:       "foreign key",
+			err:        &pgconn.PgError{Code: "23503", Message: `insert or update on table "orders" violates foreign key constraint`, ConstraintName: "orders_user_id_fkey"},
+			wantStatus: status.BadRequest,
+			wantType:   "Foreign key violation",
+			metaKey:    "constraint",
+			metaValue:  "orders_user_id_fkey",
+		},
+		{
+			name:       "not null",
+			err:        &pgconn.PgError{Code: "23502", Message: `null value in column "email" violates not-null constraint`, ColumnName: "email"},
+			wantStatus: status.BadRequest,
+			wantType:   "Missing field",
+			metaKey:    "column",
+			metaValue:  "email",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := error.FromDBError(fmt.Errorf("query: %w", tt.err), "user")
+
+			if err.PublicStatusCode != tt.wantStatus {
+				t.Errorf("unexpected public status: got %d, want %d", err.PublicStatusCode, tt.wantStatus)
+			}
+			if got := err.PublicMetaData["error_type"]; got != tt.wantType {
+				t.Errorf("unexpected error_type metadata: %q", got)
+			}
+			if got := err.ServiceMetaData["pgcode"]; got != tt.err.Code {
+				t.Errorf("unexpected pgcode metadata: %q", got)
+			}
+			if got := err.ServiceMetaData[tt.metaKey]; got != tt.metaValue {
+				t.Errorf("unexpected %s metadata: %q", tt.metaKey, got)
+			}
+			if got := error.ClassifyDBError(tt.err); got != tt.wantStatus {
+				t.Errorf("ClassifyDBError() = %d, want %d", got, tt.wantStatus)
+			}
+		})
+	}
+
+	auto := error.FromDBErrorAuto(&pgconn.PgError{Code: "23505", TableName: "accounts"})
+	if got := auto.PublicMetaData["resourceName"]; got != "accounts" {
+		t.Errorf("expected inferred entity 'accounts', got %q", got)
+	}
+}
+
+func TestFromDBError_ErrorType(t *testing.T) {
+	err := error.FromDBError(sql.ErrNoRows, "user")
+	if got := err.PublicMetaData["error_type"]; got != error.DataNotFound.String() {
+		t.Errorf("expected error_type %q, got %q", error.DataNotFound, got)
+	}
+
+	err = error.FromDBError(&pq.Error{Code: "23505"}, "user")
+	if got := err.PublicMetaData["error_type"]; got != error.DataDuplication.String() {
+		t.Errorf("expected error_type %q, got %q", error.DataDuplication, got)
+	}
+}
+
+func TestSetMaxServiceMessageLen(t *testing.T) {
+	error.SetMaxServiceMessageLen(60)
+	defer error.SetMaxServiceMessageLen(0)
+
+	long := &pq.Error{Code: "23514", Message: `new row for relation "orders" violates check constraint "orders_total_check" CHECK (total >= 0 AND total <= 1000000)`}
+	err := error.FromDBError(long, "order")
+
+	if got := []rune(err.ServiceMessage); len(got) != 63 || !strings.HasSuffix(err.ServiceMessage, "...") {
+		t.Errorf("expected a 60-character message plus an ellipsis, got %q", err.ServiceMessage)
+	}
+	if err.ServiceMetaData["truncated"] != "true" {
+		t.Error("expected the truncated flag in service metadata")
+	}
+
+	short := error.FromDBError(sql.ErrNoRows, "x")
+	if _, ok := short.ServiceMetaData["truncated"]; ok {
+		t.Errorf("expected a short message to be kept as is, got %q", short.ServiceMessage)
+	}
+}
@@ -0,0 +1,61 @@
+package error
+
+import (
+	"regexp"
+	"sort"
+)
+
+var (
+	// phonePattern requires a leading + or separated digit groups, so bare
+	// digit runs such as order IDs or Unix timestamps are not flagged.
+	phonePattern      = regexp.MustCompile(`(?:^|[^\d+])(?:\+\d{1,3}[\s.-]?\(?\d{3}\)?[\s.-]?\d{3}[\s.-]?\d{4}|(?:\(\d{3}\)\s?|\d{3}[\s.-])\d{3}[\s.-]\d{4})\b`)
+	creditCardPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+)
+
+// DetectPII returns, sorted, the public metadata keys whose values look like
+// personal data: an email address, a phone number or a credit card number
+// (checked with the Luhn algorithm). It is a defense-in-depth check meant for
+// middleware that warns about or strips such values before they are served.
+func (e *Error) DetectPII() []string {
+	var keys []string
+	for key, value := range e.PublicMetaData {
+		if looksLikePII(value) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func looksLikePII(value string) bool {
+	if emailPattern.MatchString(value) || phonePattern.MatchString(value) {
+		return true
+	}
+	for _, candidate := range creditCardPattern.FindAllString(value, -1) {
+		if luhnValid(candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum.
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
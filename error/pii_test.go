@@ -0,0 +1,54 @@
+package error_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/beka-birhanu/toddler/error"
+)
+
+func TestError_DetectPII(t *testing.T) {
+	err := &error.Error{PublicMetaData: map[string]string{
+		"contact":      "reach me at jane.doe@example.com",
+		"phone":        "+1 415-555-0132",
+		"card":         "4111 1111 1111 1111",
+		"resourceName": "user",
+		"retry_after":  "30",
+		"order_id":     "1234567812345678",
+	}}
+
+	expected := []string{"card", "contact", "phone"}
+	if got := err.DetectPII(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected flagged keys.\nExpected: %v\nGot: %v", expected, got)
+	}
+
+	if got := (&error.Error{PublicMetaData: map[string]string{"resourceName": "user"}}).DetectPII(); len(got) != 0 {
+		t.Errorf("expected no flagged keys, got %v", got)
+	}
+}
+
+func TestError_DetectPII_Phone(t *testing.T) {
+	tests := []struct {
+		value string
+		pii   bool
+	}{
+		{"+1 415-555-0132", true},
+		{"+14155550132", true},
+		{"(415) 555-0132", true},
+		{"call 415.555.0132 today", true},
+		{"415 555 0132", true},
+		{"4155550132", false},
+		{"order 1234567890", false},
+		{"1718000000", false},
+		{"account 0012345678", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			err := &error.Error{PublicMetaData: map[string]string{"value": tt.value}}
+			if got := len(err.DetectPII()) == 1; got != tt.pii {
+				t.Errorf("expected PII %v, got %v", tt.pii, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package error
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/beka-birhanu/toddler/status"
+)
+
+const (
+	// metaKeyRetryable is the service metadata key flagging an error as safe to retry.
+	metaKeyRetryable = "retryable"
+
+	// metaKeyRetryAfter is the public metadata key holding the suggested
+	// retry delay in whole seconds.
+	metaKeyRetryAfter = "retry_after"
+)
+
+// defaultRetryBackoff is the delay Retry waits when an error carries no RetryAfter.
+var defaultRetryBackoff = 100 * time.Millisecond
+
+// BackoffStrategy computes the delay to wait before retry attempt number
+// attempt, counting from 1.
+type BackoffStrategy func(attempt int) time.Duration
+
+// FixedBackoff returns a strategy that always waits d.
+func FixedBackoff(d time.Duration) BackoffStrategy {
+	return func(int) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff returns a strategy that waits base, doubling with every
+// attempt, up to max.
+func ExponentialBackoff(base, max time.Duration) BackoffStrategy {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt; i++ {
+			if d >= max/2 {
+				return max
+			}
+			d *= 2
+		}
+		return min(d, max)
+	}
+}
+
+// backoffStrategies holds the backoff strategy per status code.
+var backoffStrategies = map[status.StatusCode]BackoffStrategy{}
+
+// SetBackoffStrategy sets the strategy SuggestedDelay uses for errors with
+// the given service (or, failing that, public) status code. A nil strategy
+// removes it.
+func SetBackoffStrategy(code status.StatusCode, strategy BackoffStrategy) {
+	if strategy == nil {
+		delete(backoffStrategies, code)
+		return
+	}
+	backoffStrategies[code] = strategy
+}
+
+// SuggestedDelay returns how long to wait before retry attempt number
+// attempt, counting from 1. An explicit RetryAfter wins; otherwise the
+// strategy registered for the error's code is used, or a short default
+// backoff if there is none.
+func (e *Error) SuggestedDelay(attempt int) time.Duration {
+	if d := e.RetryAfter(); d > 0 {
+		return d
+	}
+	if strategy, ok := backoffStrategies[e.ServiceStatusCode]; ok {
+		return strategy(attempt)
+	}
+	if strategy, ok := backoffStrategies[e.PublicStatusCode]; ok {
+		return strategy(attempt)
+	}
+	return defaultRetryBackoff
+}
+
+// now is the clock used wherever the current time is needed.
+var now = time.Now
+
+// SetClock replaces the clock used for time-based computations such as
+// converting between RetryAfter and RetryAt. Passing nil restores time.Now.
+func SetClock(clock func() time.Time) {
+	if clock == nil {
+		clock = time.Now
+	}
+	now = clock
+}
+
+// IsRetryable reports whether the operation that produced the error can be
+// retried as-is, e.g. after transient lock contention.
+func (e *Error) IsRetryable() bool {
+	if e == nil {
+		return false
+	}
+	return e.ServiceMetaData[metaKeyRetryable] == "true"
+}
+
+// ShouldRetry reports whether the error IsRetryable and ctx leaves enough
+// time for another attempt: the context must not be done, and its deadline,
+// if any, must fall after the SuggestedDelay for the next attempt.
+func (e *Error) ShouldRetry(ctx context.Context) bool {
+	if !e.IsRetryable() || ctx.Err() != nil {
+		return false
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return true
+	}
+	return now().Add(e.SuggestedDelay(1)).Before(deadline)
+}
+
+// WithRetryable marks the error as retryable (or not) and returns the receiver.
+func (e *Error) WithRetryable(retryable bool) *Error {
+	if e.ServiceMetaData == nil {
+		e.ServiceMetaData = make(map[string]string)
+	}
+	if retryable {
+		e.ServiceMetaData[metaKeyRetryable] = "true"
+	} else {
+		delete(e.ServiceMetaData, metaKeyRetryable)
+	}
+	return e
+}
+
+// WithRetryAfter records how long the client should wait before retrying,
+// rounded up to whole seconds, and returns the receiver.
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	if e.PublicMetaData == nil {
+		e.PublicMetaData = make(map[string]string)
+	}
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds < 0 {
+		seconds = 0
+	}
+	e.PublicMetaData[metaKeyRetryAfter] = strconv.Itoa(seconds)
+	return e
+}
+
+// WithRetryAt records the point in time after which the client may retry.
+func (e *Error) WithRetryAt(t time.Time) *Error {
+	return e.WithRetryAfter(t.Sub(now()))
+}
+
+// RetryAfter returns the suggested retry delay, or zero if none is set.
+func (e *Error) RetryAfter() time.Duration {
+	seconds, err := strconv.Atoi(e.PublicMetaData[metaKeyRetryAfter])
+	if err != nil {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// RetryAt returns the point in time after which the client may retry,
+// or the zero time if no retry delay is set.
+func (e *Error) RetryAt() time.Time {
+	if _, ok := e.PublicMetaData[metaKeyRetryAfter]; !ok {
+		return time.Time{}
+	}
+	return now().Add(e.RetryAfter())
+}
+
+// Retry runs fn up to attempts times. It retries only while fn returns an
+// *Error that IsRetryable, waiting its SuggestedDelay between attempts. It
+// stops early when ctx is done and returns the last error returned by fn,
+// or nil on success.
+func Retry(ctx context.Context, attempts int, fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+
+		var e *Error
+		if attempt >= attempts || !errors.As(err, &e) || !e.IsRetryable() {
+			return err
+		}
+
+		timer := time.NewTimer(e.SuggestedDelay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package error_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/beka-birhanu/toddler/error"
+	"github.com/beka-birhanu/toddler/status"
+)
+
+func TestSetClock(t *testing.T) {
+	fixed := time.Date(2025, 5, 6, 10, 0, 0, 0, time.UTC)
+	error.SetClock(func() time.Time { return fixed })
+	defer error.SetClock(nil)
+
+	err := error.Must(status.ServerError).WithRetryAt(fixed.Add(30 * time.Second))
+
+	if got := err.HeaderMap()["Retry-After"]; got != "30" {
+		t.Errorf("unexpected Retry-After header: %q", got)
+	}
+	if got := err.RetryAfter(); got != 30*time.Second {
+		t.Errorf("unexpected RetryAfter: %v", got)
+	}
+	if got := err.RetryAt(); !got.Equal(fixed.Add(30 * time.Second)) {
+		t.Errorf("unexpected RetryAt: %v", got)
+	}
+}
+
+func TestRetry_FailThenSucceed(t *testing.T) {
+	calls := 0
+	err := error.Retry(context.Background(), 3, func() builtinError {
+		calls++
+		if calls < 2 {
+			return error.Must(status.Conflict).WithRetryable(true)
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Errorf("expected success after retry, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestRetry_NonRetryable(t *testing.T) {
+	calls := 0
+	want := error.Must(status.BadRequest)
+	err := error.Retry(context.Background(), 3, func() builtinError {
+		calls++
+		return want
+	})
+
+	if !errors.Is(err, want) {
+		t.Errorf("expected the non-retryable error to be returned, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected a single call, got %d", calls)
+	}
+}
+
+func TestRetry_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	err := error.Retry(ctx, 5, func() builtinError {
+		calls++
+		return error.Must(status.Conflict).WithRetryable(true)
+	})
+
+	if err == nil {
+		t.Error("expected the last error to be returned")
+	}
+	if calls != 1 {
+		t.Errorf("expected retries to stop on a canceled context, got %d calls", calls)
+	}
+}
+
+func TestSetBackoffStrategy_Fixed(t *testing.T) {
+	error.SetBackoffStrategy(status.ConflictLocked, error.FixedBackoff(250*time.Millisecond))
+	defer error.SetBackoffStrategy(status.ConflictLocked, nil)
+
+	err := error.Must(status.ConflictLocked)
+	for _, attempt := range []int{1, 2, 5} {
+		if got := err.SuggestedDelay(attempt); got != 250*time.Millisecond {
+			t.Errorf("attempt %d: got %v, want 250ms", attempt, got)
+		}
+	}
+
+	err.WithRetryAfter(3 * time.Second)
+	if got := err.SuggestedDelay(1); got != 3*time.Second {
+		t.Errorf("expected explicit RetryAfter to win, got %v", got)
+	}
+}
+
+func TestSetBackoffStrategy_Exponential(t *testing.T) {
+	error.SetBackoffStrategy(status.ServerErrorUnavailable, error.ExponentialBackoff(100*time.Millisecond, time.Second))
+	defer error.SetBackoffStrategy(status.ServerErrorUnavailable, nil)
+
+	err := error.Must(status.ServerErrorUnavailable)
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, want := range expected {
+		if got := err.SuggestedDelay(i + 1); got != want {
+			t.Errorf("attempt %d: got %v, want %v", i+1, got, want)
+		}
+	}
+
+	if got := error.Must(status.Conflict).SuggestedDelay(1); got != 100*time.Millisecond {
+		t.Errorf("expected the default backoff without a strategy, got %v", got)
+	}
+}
+
+func TestError_ShouldRetry(t *testing.T) {
+	err := error.Must(status.ServerErrorUnavailable).WithRetryable(true).WithRetryAfter(2 * time.Second)
+
+	ample, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if !err.ShouldRetry(ample) {
+		t.Error("expected a retry to fit within an ample deadline")
+	}
+
+	tight, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err.ShouldRetry(tight) {
+		t.Error("expected no retry when the delay exceeds the remaining deadline")
+	}
+
+	if !err.ShouldRetry(context.Background()) {
+		t.Error("expected a retry without a deadline")
+	}
+	if error.Must(status.BadRequest).ShouldRetry(ample) {
+		t.Error("expected a non-retryable error not to be retried")
+	}
+}
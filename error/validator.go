@@ -2,6 +2,8 @@ package error
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/beka-birhanu/toddler/status"
@@ -37,8 +39,36 @@ var formatTags = map[string]status.StatusCode{
 	"image":         status.BadRequestInvalidFormat,
 }
 
+var richFormatTags = map[string]status.StatusCode{
+	"iscolor":   status.BadRequestInvalidFormat,
+	"e164":      status.BadRequestInvalidFormat,
+	"datetime":  status.BadRequestInvalidFormat,
+	"latitude":  status.BadRequestInvalidFormat,
+	"longitude": status.BadRequestInvalidFormat,
+}
+
+var networkFormatTags = map[string]status.StatusCode{
+	"ip":       status.BadRequestInvalidFormat,
+	"cidr":     status.BadRequestInvalidFormat,
+	"url":      status.BadRequestInvalidFormat,
+	"hostname": status.BadRequestInvalidFormat,
+	"fqdn":     status.BadRequestInvalidFormat,
+}
+
+var charClassTags = map[string]status.StatusCode{
+	"numeric":  status.BadRequestInvalidFormat,
+	"alpha":    status.BadRequestInvalidFormat,
+	"alphanum": status.BadRequestInvalidFormat,
+}
+
+var typeTags = map[string]status.StatusCode{
+	"boolean": status.BadRequestTypeMismatch,
+	"number":  status.BadRequestTypeMismatch,
+}
+
 var enumTags = map[string]status.StatusCode{
-	"oneof": status.BadRequestEnumViolation,
+	"oneof":   status.BadRequestEnumViolation,
+	"oneofci": status.BadRequestEnumViolation,
 }
 
 var valueConstraintTags = map[string]status.StatusCode{
@@ -61,6 +91,138 @@ var rangeTags = map[string]status.StatusCode{
 
 var fallbackStatusCode = status.BadRequest
 
+// registeredTag describes a custom validation tag added by RegisterValidationTag.
+type registeredTag struct {
+	code   status.StatusCode
+	reason func(field, param string) string
+}
+
+// registeredTags holds custom validation tags; they take precedence over the
+// built-in categories.
+var registeredTags = map[string]registeredTag{}
+
+// RegisterValidationTag teaches the validation mappers about a custom tag:
+// failures on tag are reported with code and, when reason is non-nil, the
+// reason it builds from the field name and tag param. Registering a built-in
+// tag overrides it.
+func RegisterValidationTag(tag string, code status.StatusCode, reason func(field, param string) string) {
+	registeredTags[tag] = registeredTag{code: code, reason: reason}
+}
+
+// UnregisterValidationTag removes a tag registered with
+// RegisterValidationTag, restoring the built-in handling if any.
+func UnregisterValidationTag(tag string) {
+	delete(registeredTags, tag)
+}
+
+// KnownValidationTags returns, sorted, every tag the mappers report with a
+// tailored status code, across the built-in categories and tags registered
+// with RegisterValidationTag. Other tags get the fallback code and reason.
+func KnownValidationTags() []string {
+	seen := make(map[string]struct{})
+	for _, m := range []map[string]status.StatusCode{
+		requiredTags, formatTags, richFormatTags, networkFormatTags,
+		charClassTags, typeTags, enumTags, valueConstraintTags, rangeTags,
+	} {
+		for tag := range m {
+			seen[tag] = struct{}{}
+		}
+	}
+	for tag := range registeredTags {
+		seen[tag] = struct{}{}
+	}
+
+	tags := make([]string, 0, len(seen))
+	for tag := range seen {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// sortValidationFields makes FromValidationErrors report fields alphabetically.
+var sortValidationFields = false
+
+// SetSortValidationFields controls whether FromValidationErrors sorts the
+// reported fields alphabetically instead of keeping the validator's order.
+func SetSortValidationFields(enabled bool) {
+	sortValidationFields = enabled
+}
+
+// failureSeparator joins the per-field reasons in the public "failures" metadata.
+var failureSeparator = "; "
+
+// SetFailureSeparator sets the separator used to join per-field reasons in
+// the public "failures" metadata. It defaults to "; ". Clients that need to
+// parse individual failures should prefer the structured Error.FieldErrors.
+func SetFailureSeparator(sep string) {
+	failureSeparator = sep
+}
+
+// echoInvalidEnumValue makes enum reasons include the rejected value.
+var echoInvalidEnumValue = false
+
+// maxEchoedValueLen caps the length of a value echoed back in a reason.
+const maxEchoedValueLen = 32
+
+// EchoInvalidEnumValue controls whether oneof/oneofci reasons echo back the
+// rejected value, e.g. "'purple' is not valid; Color must be one of: red, green".
+// Long values are truncated and redacted fields stay masked.
+func EchoInvalidEnumValue(enabled bool) {
+	echoInvalidEnumValue = enabled
+}
+
+// redactedValue replaces the value of redacted fields.
+const redactedValue = "[REDACTED]"
+
+// redactedFields holds lower-cased field names whose values are never reported.
+var redactedFields = map[string]struct{}{}
+
+// RedactFields marks fields whose values must be masked in validation errors,
+// both in the FieldValidationError slice and in service messages.
+// Names are matched case-insensitively against the field name.
+func RedactFields(names ...string) {
+	for _, name := range names {
+		redactedFields[strings.ToLower(name)] = struct{}{}
+	}
+}
+
+// UnredactFields reverts RedactFields for the given names.
+func UnredactFields(names ...string) {
+	for _, name := range names {
+		delete(redactedFields, strings.ToLower(name))
+	}
+}
+
+// fieldAliases maps validator namespaces to public field names.
+var fieldAliases = map[string]string{}
+
+// RegisterFieldAlias makes validation errors for the field at namespace
+// (e.g. "CreateUserRequest.Profile.Email") report alias (e.g. "email") as
+// the field name. Unregistered fields use their leaf field name.
+func RegisterFieldAlias(namespace, alias string) {
+	fieldAliases[namespace] = alias
+}
+
+// UnregisterFieldAlias removes the alias registered for namespace.
+func UnregisterFieldAlias(namespace string) {
+	delete(fieldAliases, namespace)
+}
+
+func fieldName(fe validator.FieldError) string {
+	if alias, ok := fieldAliases[fe.Namespace()]; ok {
+		return alias
+	}
+	return fe.Field()
+}
+
+func fieldValue(fe validator.FieldError) any {
+	if _, ok := redactedFields[strings.ToLower(fe.Field())]; ok {
+		return redactedValue
+	}
+	return fe.Value()
+}
+
 func FromValidationErrors(err error) *Error {
 	if err == nil {
 		return nil
@@ -75,33 +237,76 @@ func FromValidationErrors(err error) *Error {
 			PublicMessage:     "Invalid input provided",
 			ServiceMessage:    fmt.Sprintf("Unknown validation error: %v", err),
 			PublicMetaData: map[string]string{
-				"error_type": "Validation",
+				"error_type": Validation.String(),
 			},
 			ServiceMetaData: map[string]string{
-				"error_type": "ValidatorErrorUnknown",
+				"error_type": ValidatorErrorUnknown.String(),
 				"raw_error":  err.Error(),
 			},
 		}
 	}
 
+	if len(ve) == 0 {
+		// Custom validators can report failure without any field errors
+		return &Error{
+			PublicStatusCode:  status.BadRequest,
+			ServiceStatusCode: status.BadRequest,
+			PublicMessage:     "Invalid input provided",
+			ServiceMessage:    "Validation failed without reporting any field errors",
+			PublicMetaData: map[string]string{
+				"error_type": Validation.String(),
+			},
+			ServiceMetaData: map[string]string{
+				"error_type": ValidatorEmptyErrors.String(),
+			},
+		}
+	}
+
 	fieldErrors := MapValidationErrors(ve)
+	if sortValidationFields {
+		sort.SliceStable(fieldErrors, func(i, j int) bool {
+			return fieldErrors[i].Field < fieldErrors[j].Field
+		})
+	}
+
+	return FromFieldErrors(fieldErrors)
+}
+
+// ValidateStruct validates s with v and returns the failure as an *Error,
+// built by FromValidationErrors, or nil when s is valid.
+func ValidateStruct(v *validator.Validate, s any) *Error {
+	if err := v.Struct(s); err != nil {
+		return FromValidationErrors(err)
+	}
+	return nil
+}
 
+// FromFieldErrors aggregates field errors, whether produced by the validator
+// or built by hand with arbitrary codes, into a single structured error.
+// The overall status is the highest-precedence code among the fields, so a
+// request whose referenced entity is missing (404) wins over plain 400s;
+// the per-field codes remain available through FieldErrors.
+//
+// Form-level entries (see FormError) are not attributed to any field; their
+// reasons are reported under the public "form_errors" key instead.
+func FromFieldErrors(fieldErrors []*FieldValidationError) *Error {
 	// Combine messages and metadata
 	fields := make([]string, 0, len(fieldErrors))
 	publicMessages := make([]string, 0, len(fieldErrors))
+	var formMessages []string
 	serviceMessages := make([]string, 0, len(fieldErrors))
 	publicMeta := make(map[string]string)
 	serviceMeta := make(map[string]string)
 
-	// Select the "most specific" highest severity code (use the first one by default)
-	var finalStatus status.StatusCode
-	if len(fields) != 1 {
-		finalStatus = status.BadRequest
-	} else {
-		finalStatus = fieldErrors[0].StatusCode
-	}
+	finalStatus := overallValidationStatus(fieldErrors)
 
 	for _, fe := range fieldErrors {
+		if fe.IsFormLevel() {
+			formMessages = append(formMessages, fe.Reason)
+			serviceMessages = append(serviceMessages, fmt.Sprintf("Form failed on '%s'", fe.ValidationTag))
+			continue
+		}
+
 		publicMessages = append(publicMessages, fmt.Sprintf("%s: %s", fe.Field, fe.Reason))
 		serviceMessages = append(serviceMessages, fmt.Sprintf("Field '%s' with value '%v' failed on '%s'", fe.Field, fe.Value, fe.ValidationTag))
 		fields = append(fields, fe.Field)
@@ -111,22 +316,93 @@ func FromValidationErrors(err error) *Error {
 		serviceMeta[fe.Field+"status_code"] = fmt.Sprintf("%d", fe.StatusCode)
 	}
 
-	return &Error{
+	e := &Error{
 		PublicStatusCode:  finalStatus,
 		ServiceStatusCode: finalStatus,
 		PublicMessage:     "Invalid input in one or more fields",
 		ServiceMessage:    strings.Join(serviceMessages, "; "),
 		PublicMetaData: map[string]string{
-			"error_type": "Validation",
+			"error_type": Validation.String(),
 			"fields":     strings.Join(fields, ", "),
-			"failures":   strings.Join(publicMessages, "; "),
+			"failures":   strings.Join(publicMessages, failureSeparator),
 		},
 		ServiceMetaData: map[string]string{
-			"error_type": "ValidatorFieldErrors",
+			"error_type": ValidatorFieldErrors.String(),
 			"fields":     strings.Join(fields, ", "),
 			"details":    fmt.Sprintf("%v", serviceMeta),
 		},
+		fieldErrors: fieldErrors,
+	}
+	if len(formMessages) > 0 {
+		e.PublicMetaData["form_errors"] = strings.Join(formMessages, failureSeparator)
+	}
+	return e
+}
+
+// WithFieldErrors attaches fes to the error, replacing any field errors it
+// already carries, and regenerates the public "fields", "failures" and
+// "form_errors" metadata from them. The status codes are left untouched.
+// It returns the receiver.
+func (e *Error) WithFieldErrors(fes []*FieldValidationError) *Error {
+	built := FromFieldErrors(fes)
+	if e.PublicMetaData == nil {
+		e.PublicMetaData = make(map[string]string)
+	}
+	for _, key := range []string{"fields", "failures", "form_errors"} {
+		if value, ok := built.PublicMetaData[key]; ok {
+			e.PublicMetaData[key] = value
+		} else {
+			delete(e.PublicMetaData, key)
+		}
 	}
+	e.fieldErrors = fes
+	return e
+}
+
+// ClassifyValidationError returns the status code FromValidationErrors would
+// assign to err, without building the full *Error. It returns 0 for a nil error.
+func ClassifyValidationError(err error) status.StatusCode {
+	if err == nil {
+		return 0
+	}
+	ve, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return status.BadRequest
+	}
+	return overallValidationStatus(MapValidationErrors(ve))
+}
+
+// overallValidationStatus selects the code reported for a set of field errors.
+func overallValidationStatus(fieldErrors []*FieldValidationError) status.StatusCode {
+	codes := make([]status.StatusCode, 0, len(fieldErrors))
+	for _, fe := range fieldErrors {
+		codes = append(codes, fe.StatusCode)
+	}
+	return overallStatus(codes)
+}
+
+// overallStatus selects the single code reported for several failures.
+// Categories are ranked by status.MoreSevere; when several distinct codes
+// share the winning category, their generic category code is reported
+// instead (e.g. BadRequest for a missing field plus an invalid format). It
+// returns BadRequest for no codes.
+func overallStatus(codes []status.StatusCode) status.StatusCode {
+	if len(codes) == 0 {
+		return status.BadRequest
+	}
+
+	overall := codes[0]
+	for _, code := range codes[1:] {
+		switch {
+		case status.Group(code) == status.Group(overall):
+			if code != overall {
+				overall = status.Group(overall)
+			}
+		case status.MoreSevere(code, overall):
+			overall = code
+		}
+	}
+	return overall
 }
 
 type FieldValidationError struct {
@@ -137,13 +413,34 @@ type FieldValidationError struct {
 	StatusCode    status.StatusCode `json:"status_code"`
 }
 
+// FormField is the Field of a form-level validation error, one that concerns
+// the input as a whole rather than a single field.
+const FormField = "_form"
+
+// FormError returns a form-level validation error, such as "at least one
+// contact method is required", for use with FromFieldErrors.
+func FormError(tag, reason string) *FieldValidationError {
+	return &FieldValidationError{
+		Field:         FormField,
+		Reason:        reason,
+		ValidationTag: tag,
+		StatusCode:    status.BadRequest,
+	}
+}
+
+// IsFormLevel reports whether fe concerns the whole form rather than a single
+// field, i.e. its Field is FormField or empty.
+func (fe *FieldValidationError) IsFormLevel() bool {
+	return fe.Field == FormField || fe.Field == ""
+}
+
 func MapValidationErrors(ve validator.ValidationErrors) []*FieldValidationError {
 	var result []*FieldValidationError
 
 	for _, fe := range ve {
 		result = append(result, &FieldValidationError{
-			Field:         fe.Field(),
-			Value:         fe.Value(),
+			Field:         fieldName(fe),
+			Value:         fieldValue(fe),
 			Reason:        generateReason(fe),
 			ValidationTag: fe.Tag(),
 			StatusCode:    mapTagToStatusCode(fe),
@@ -159,20 +456,163 @@ func generateReason(fe validator.FieldError) string {
 		return ok
 	}
 	tag := fe.Tag()
-	field := fe.Field()
+	field := fieldName(fe)
 	param := fe.Param()
 
+	if custom, ok := registeredTags[tag]; ok && custom.reason != nil {
+		return custom.reason(field, param)
+	}
+
 	switch {
 	case isInMap(requiredTags, tag):
-		return fmt.Sprintf("%s is required", field)
+		return requiredReason(field, tag, param)
 	case isInMap(formatTags, tag):
 		return fmt.Sprintf("%s must be a valid %s", field, tag)
+	case isInMap(richFormatTags, tag):
+		return richFormatReason(field, tag, param)
+	case isInMap(networkFormatTags, tag):
+		return networkFormatReason(field, tag)
+	case isInMap(charClassTags, tag):
+		return charClassReason(field, tag)
+	case isInMap(typeTags, tag):
+		return fmt.Sprintf("%s must be a %s", field, tag)
+	case tag == "unique":
+		if param != "" {
+			return fmt.Sprintf("%s must not contain items with duplicate %s values", field, param)
+		}
+		return fmt.Sprintf("%s must not contain duplicate values", field)
 	case tag == "len":
 		return fmt.Sprintf("%s must be exactly %s characters", field, param)
 	case isInMap(rangeTags, tag):
 		return fmt.Sprintf("%s must be %s %s", field, tag, param)
 	case isInMap(enumTags, tag):
-		return fmt.Sprintf("%s must be one of [%s]", field, param)
+		return enumReason(fe, field, tag, param)
+	default:
+		return fmt.Sprintf("%s failed validation: %s", field, tag)
+	}
+}
+
+func enumReason(fe validator.FieldError, field, tag, param string) string {
+	reason := fmt.Sprintf("%s must be one of: %s", field, strings.Join(parseOneOfParam(param), ", "))
+	if tag == "oneofci" {
+		reason += " (case-insensitive)"
+	}
+	if echoInvalidEnumValue {
+		reason = fmt.Sprintf("'%s' is not valid; %s", truncateValue(fmt.Sprintf("%v", fieldValue(fe))), reason)
+	}
+	return reason
+}
+
+// truncateValue shortens values echoed back to clients.
+func truncateValue(value string) string {
+	if runes := []rune(value); len(runes) > maxEchoedValueLen {
+		return string(runes[:maxEchoedValueLen]) + "..."
+	}
+	return value
+}
+
+func requiredReason(field, tag, param string) string {
+	related := strings.Fields(param)
+	if len(related) == 0 {
+		return fmt.Sprintf("%s is required", field)
+	}
+
+	switch tag {
+	case "required_with":
+		return fmt.Sprintf("%s is required when %s is present", field, joinFieldList(related, "or"))
+	case "required_without":
+		return fmt.Sprintf("%s is required when %s is absent", field, joinFieldList(related, "or"))
+	case "required_with_all":
+		return fmt.Sprintf("%s is required when %s", field, allFieldsClause(related, "present"))
+	case "required_without_all":
+		return fmt.Sprintf("%s is required when %s", field, allFieldsClause(related, "absent"))
+	default:
+		return fmt.Sprintf("%s is required", field)
+	}
+}
+
+// allFieldsClause states that every related field is in the given state,
+// e.g. "A is present" or "A, B, and C are all present".
+func allFieldsClause(fields []string, state string) string {
+	if len(fields) == 1 {
+		return fmt.Sprintf("%s is %s", fields[0], state)
+	}
+	return fmt.Sprintf("%s are all %s", joinFieldList(fields, "and"), state)
+}
+
+// joinFieldList renders field names as a readable list using conj before the
+// last item, e.g. "A", "A or B", "A, B, or C".
+func joinFieldList(fields []string, conj string) string {
+	switch len(fields) {
+	case 0:
+		return ""
+	case 1:
+		return fields[0]
+	case 2:
+		return fields[0] + " " + conj + " " + fields[1]
+	default:
+		return strings.Join(fields[:len(fields)-1], ", ") + ", " + conj + " " + fields[len(fields)-1]
+	}
+}
+
+// oneOfValuePattern matches a single oneof option, either 'quoted' or bare,
+// mirroring how the validator splits the param.
+var oneOfValuePattern = regexp.MustCompile(`'[^']*'|\S+`)
+
+// parseOneOfParam splits a oneof param into its options, unquoting
+// multi-word values such as 'new york'.
+func parseOneOfParam(param string) []string {
+	values := oneOfValuePattern.FindAllString(param, -1)
+	for i, value := range values {
+		if len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+			values[i] = value[1 : len(value)-1]
+		}
+	}
+	return values
+}
+
+func richFormatReason(field, tag, param string) string {
+	switch tag {
+	case "iscolor":
+		return fmt.Sprintf("%s must be a valid color", field)
+	case "e164":
+		return fmt.Sprintf("%s must be a valid E.164 phone number", field)
+	case "datetime":
+		return fmt.Sprintf("%s must be a valid datetime in the format %s", field, param)
+	case "latitude":
+		return fmt.Sprintf("%s must be a valid latitude", field)
+	case "longitude":
+		return fmt.Sprintf("%s must be a valid longitude", field)
+	default:
+		return fmt.Sprintf("%s must be a valid %s", field, tag)
+	}
+}
+
+func networkFormatReason(field, tag string) string {
+	switch tag {
+	case "ip":
+		return fmt.Sprintf("%s must be a valid IP address", field)
+	case "cidr":
+		return fmt.Sprintf("%s must be a valid CIDR notation address", field)
+	case "url":
+		return fmt.Sprintf("%s must be a valid URL", field)
+	case "hostname":
+		return fmt.Sprintf("%s must be a valid hostname", field)
+	case "fqdn":
+		return fmt.Sprintf("%s must be a fully qualified domain name", field)
+	default:
+		return fmt.Sprintf("%s must be a valid %s", field, tag)
+	}
+}
+
+func charClassReason(field, tag string) string {
+	switch tag {
+	case "numeric":
+		return fmt.Sprintf("%s must be a numeric value", field)
+	case "alpha":
+		return fmt.Sprintf("%s must contain only letters", field)
+	case "alphanum":
+		return fmt.Sprintf("%s must contain only letters and digits", field)
 	default:
 		return fmt.Sprintf("%s failed validation: %s", field, tag)
 	}
@@ -181,12 +621,27 @@ func generateReason(fe validator.FieldError) string {
 func mapTagToStatusCode(fe validator.FieldError) status.StatusCode {
 	tag := fe.Tag()
 
+	if custom, ok := registeredTags[tag]; ok {
+		return custom.code
+	}
 	if code, ok := requiredTags[tag]; ok {
 		return code
 	}
 	if code, ok := formatTags[tag]; ok {
 		return code
 	}
+	if code, ok := richFormatTags[tag]; ok {
+		return code
+	}
+	if code, ok := networkFormatTags[tag]; ok {
+		return code
+	}
+	if code, ok := charClassTags[tag]; ok {
+		return code
+	}
+	if code, ok := typeTags[tag]; ok {
+		return code
+	}
 	if code, ok := enumTags[tag]; ok {
 		return code
 	}
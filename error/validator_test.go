@@ -0,0 +1,477 @@
+package error_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/beka-birhanu/toddler/error"
+	"github.com/beka-birhanu/toddler/status"
+	"github.com/go-playground/validator/v10"
+)
+
+func TestRedactFields(t *testing.T) {
+	error.RedactFields("password")
+	t.Cleanup(func() { error.UnredactFields("password") })
+
+	input := struct {
+		Password string `validate:"min=8"`
+	}{Password: "hunter2"}
+
+	verr := validator.New().Struct(input)
+	fieldErrors := error.MapValidationErrors(verr.(validator.ValidationErrors))
+	if len(fieldErrors) != 1 {
+		t.Fatalf("expected 1 field error, got %d", len(fieldErrors))
+	}
+	if fieldErrors[0].Value != "[REDACTED]" {
+		t.Errorf("expected value to be redacted, got %v", fieldErrors[0].Value)
+	}
+
+	err := error.FromValidationErrors(verr)
+	if strings.Contains(err.ServiceMessage, "hunter2") {
+		t.Errorf("service message leaks redacted value: %s", err.ServiceMessage)
+	}
+
+	error.UnredactFields("Password")
+	if fe := error.MapValidationErrors(verr.(validator.ValidationErrors))[0]; fe.Value != "hunter2" {
+		t.Errorf("expected the value after unredacting, got %v", fe.Value)
+	}
+}
+
+// validateField validates value, held in a struct field named "Field" tagged
+// with tag, and returns the single resulting field error.
+func validateField(t *testing.T, value any, tag string) *error.FieldValidationError {
+	t.Helper()
+	typ := reflect.StructOf([]reflect.StructField{{
+		Name: "Field",
+		Type: reflect.TypeOf(value),
+		Tag:  reflect.StructTag(`validate:"` + tag + `"`),
+	}})
+	input := reflect.New(typ).Elem()
+	input.Field(0).Set(reflect.ValueOf(value))
+
+	verr := validator.New().Struct(input.Interface())
+	if verr == nil {
+		t.Fatalf("expected %v to fail validation %q", value, tag)
+	}
+	fieldErrors := error.MapValidationErrors(verr.(validator.ValidationErrors))
+	if len(fieldErrors) != 1 {
+		t.Fatalf("expected 1 field error, got %d", len(fieldErrors))
+	}
+	return fieldErrors[0]
+}
+
+func TestMapValidationErrors_FormatTags(t *testing.T) {
+	tests := []struct {
+		tag    string
+		value  any
+		code   status.StatusCode
+		reason string
+	}{
+		{"iscolor", "not-a-color", status.BadRequestInvalidFormat, "Field must be a valid color"},
+		{"e164", "12345", status.BadRequestInvalidFormat, "Field must be a valid E.164 phone number"},
+		{"datetime=2006-01-02", "02/01/2006", status.BadRequestInvalidFormat, "Field must be a valid datetime in the format 2006-01-02"},
+		{"latitude", "200", status.BadRequestInvalidFormat, "Field must be a valid latitude"},
+		{"longitude", "200", status.BadRequestInvalidFormat, "Field must be a valid longitude"},
+		{"numeric", "12a", status.BadRequestInvalidFormat, "Field must be a numeric value"},
+		{"alpha", "abc1", status.BadRequestInvalidFormat, "Field must contain only letters"},
+		{"alphanum", "abc-1", status.BadRequestInvalidFormat, "Field must contain only letters and digits"},
+		{"ip", "999.1.1.1", status.BadRequestInvalidFormat, "Field must be a valid IP address"},
+		{"cidr", "10.0.0.0", status.BadRequestInvalidFormat, "Field must be a valid CIDR notation address"},
+		{"url", "not a url", status.BadRequestInvalidFormat, "Field must be a valid URL"},
+		{"hostname", "bad_host!", status.BadRequestInvalidFormat, "Field must be a valid hostname"},
+		{"fqdn", "localhost", status.BadRequestInvalidFormat, "Field must be a fully qualified domain name"},
+		{"boolean", "yes please", status.BadRequestTypeMismatch, "Field must be a boolean"},
+		{"number", "12a", status.BadRequestTypeMismatch, "Field must be a number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			fe := validateField(t, tt.value, tt.tag)
+			if fe.StatusCode != tt.code {
+				t.Errorf("unexpected status code.\nExpected: %d\nGot: %d", tt.code, fe.StatusCode)
+			}
+			if fe.Reason != tt.reason {
+				t.Errorf("unexpected reason.\nExpected: %q\nGot: %q", tt.reason, fe.Reason)
+			}
+		})
+	}
+}
+
+func TestSetSortValidationFields(t *testing.T) {
+	error.SetSortValidationFields(true)
+	defer error.SetSortValidationFields(false)
+
+	input := struct {
+		Zeta  string `validate:"required"`
+		Alpha string `validate:"required"`
+		Mid   string `validate:"required"`
+	}{}
+
+	err := error.FromValidationErrors(validator.New().Struct(input))
+
+	if got := err.PublicMetaData["fields"]; got != "Alpha, Mid, Zeta" {
+		t.Errorf("expected sorted fields, got %q", got)
+	}
+	expectedFailures := "Alpha: Alpha is required; Mid: Mid is required; Zeta: Zeta is required"
+	if got := err.PublicMetaData["failures"]; got != expectedFailures {
+		t.Errorf("expected sorted failures, got %q", got)
+	}
+}
+
+func TestMapValidationErrors_OneOfReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		tag    string
+		reason string
+	}{
+		{"simple", "oneof=red green blue", "Field must be one of: red, green, blue"},
+		{"quoted", "oneof='new york' boston", "Field must be one of: new york, boston"},
+		{"case-insensitive", "oneofci=red green blue", "Field must be one of: red, green, blue (case-insensitive)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fe := validateField(t, "purple", tt.tag)
+			if fe.StatusCode != status.BadRequestEnumViolation {
+				t.Errorf("unexpected status code: %d", fe.StatusCode)
+			}
+			if fe.Reason != tt.reason {
+				t.Errorf("unexpected reason.\nExpected: %q\nGot: %q", tt.reason, fe.Reason)
+			}
+		})
+	}
+}
+
+func TestRegisterFieldAlias(t *testing.T) {
+	type Profile struct {
+		Email string `validate:"required"`
+		Name  string `validate:"required"`
+	}
+	type CreateUserRequest struct {
+		Profile Profile
+	}
+
+	error.RegisterFieldAlias("CreateUserRequest.Profile.Email", "email")
+	t.Cleanup(func() { error.UnregisterFieldAlias("CreateUserRequest.Profile.Email") })
+
+	verr := validator.New().Struct(CreateUserRequest{})
+	fieldErrors := error.MapValidationErrors(verr.(validator.ValidationErrors))
+	if len(fieldErrors) != 2 {
+		t.Fatalf("expected 2 field errors, got %d", len(fieldErrors))
+	}
+
+	if fieldErrors[0].Field != "email" {
+		t.Errorf("expected aliased field name, got %q", fieldErrors[0].Field)
+	}
+	if fieldErrors[0].Reason != "email is required" {
+		t.Errorf("unexpected reason: %q", fieldErrors[0].Reason)
+	}
+	if fieldErrors[1].Field != "Name" {
+		t.Errorf("expected fallback to the leaf field name, got %q", fieldErrors[1].Field)
+	}
+
+	error.UnregisterFieldAlias("CreateUserRequest.Profile.Email")
+	if fe := error.MapValidationErrors(verr.(validator.ValidationErrors))[0]; fe.Field != "Email" {
+		t.Errorf("expected the leaf field name after unregistering, got %q", fe.Field)
+	}
+}
+
+func TestSetFailureSeparator(t *testing.T) {
+	error.SetFailureSeparator(" | ")
+	defer error.SetFailureSeparator("; ")
+
+	input := struct {
+		Name  string `validate:"required"`
+		Email string `validate:"required"`
+	}{}
+
+	err := error.FromValidationErrors(validator.New().Struct(input))
+
+	expected := "Name: Name is required | Email: Email is required"
+	if got := err.PublicMetaData["failures"]; got != expected {
+		t.Errorf("unexpected failures.\nExpected: %q\nGot: %q", expected, got)
+	}
+	if got := len(err.FieldErrors()); got != 2 {
+		t.Errorf("expected 2 structured field errors, got %d", got)
+	}
+}
+
+func TestFromValidationErrors_Empty(t *testing.T) {
+	err := error.FromValidationErrors(validator.ValidationErrors{})
+
+	if err == nil {
+		t.Fatal("expected an error for empty ValidationErrors")
+	}
+	if err.PublicStatusCode != status.BadRequest {
+		t.Errorf("unexpected public status: %d", err.PublicStatusCode)
+	}
+	if err.PublicMessage == "" || err.ServiceMessage == "" {
+		t.Errorf("expected non-empty messages, got %q / %q", err.PublicMessage, err.ServiceMessage)
+	}
+	if len(err.FieldErrors()) != 0 {
+		t.Errorf("expected no field errors, got %d", len(err.FieldErrors()))
+	}
+}
+
+func TestValidateStruct(t *testing.T) {
+	type request struct {
+		Email string `validate:"required,email"`
+	}
+	v := validator.New()
+
+	if err := error.ValidateStruct(v, request{Email: "a@example.com"}); err != nil {
+		t.Errorf("expected a valid struct to pass, got %v", err)
+	}
+
+	err := error.ValidateStruct(v, request{})
+	if err == nil {
+		t.Fatal("expected an invalid struct to fail")
+	}
+	if err.PublicStatusCode != status.BadRequestMissingField {
+		t.Errorf("unexpected public status: %d", err.PublicStatusCode)
+	}
+	if got := err.PublicMetaData["fields"]; got != "Email" {
+		t.Errorf("unexpected fields metadata: %q", got)
+	}
+}
+
+func TestKnownValidationTags(t *testing.T) {
+	error.RegisterValidationTag("sku", status.BadRequestInvalidFormat, func(field, _ string) string {
+		return field + " must be a valid SKU"
+	})
+	t.Cleanup(func() { error.UnregisterValidationTag("sku") })
+
+	known := map[string]bool{}
+	for _, tag := range error.KnownValidationTags() {
+		known[tag] = true
+	}
+	for _, tag := range []string{"required", "email", "iscolor", "ip", "numeric", "oneof", "eq", "min", "sku"} {
+		if !known[tag] {
+			t.Errorf("expected %q to be a known tag", tag)
+		}
+	}
+	if known["sometag"] {
+		t.Error("expected unknown tags to be absent")
+	}
+
+	v := validator.New()
+	_ = v.RegisterValidation("sku", func(fl validator.FieldLevel) bool {
+		return strings.HasPrefix(fl.Field().String(), "SKU-")
+	})
+	input := struct {
+		Code string `validate:"sku"`
+	}{Code: "123"}
+	fe := error.MapValidationErrors(v.Struct(input).(validator.ValidationErrors))[0]
+	if fe.StatusCode != status.BadRequestInvalidFormat {
+		t.Errorf("unexpected status code for registered tag: %d", fe.StatusCode)
+	}
+	if fe.Reason != "Code must be a valid SKU" {
+		t.Errorf("unexpected reason for registered tag: %q", fe.Reason)
+	}
+}
+
+func TestClassifyValidationError(t *testing.T) {
+	single := struct {
+		Email string `validate:"required"`
+	}{}
+	verr := validator.New().Struct(single)
+	if got := error.ClassifyValidationError(verr); got != status.BadRequestMissingField {
+		t.Errorf("single field: got %d, want %d", got, status.BadRequestMissingField)
+	}
+	if got := error.FromValidationErrors(verr).PublicStatusCode; got != status.BadRequestMissingField {
+		t.Errorf("single field: FromValidationErrors disagrees, got %d", got)
+	}
+
+	multi := struct {
+		Email string `validate:"required"`
+		Age   int    `validate:"gte=18"`
+	}{}
+	verr = validator.New().Struct(multi)
+	if got := error.ClassifyValidationError(verr); got != status.BadRequest {
+		t.Errorf("multi field: got %d, want %d", got, status.BadRequest)
+	}
+	if got := error.FromValidationErrors(verr).PublicStatusCode; got != status.BadRequest {
+		t.Errorf("multi field: FromValidationErrors disagrees, got %d", got)
+	}
+}
+
+func TestMapValidationErrors_RequiredWithReasons(t *testing.T) {
+	type Input struct {
+		Phone   string
+		Email   string
+		Country string `validate:"required_with=Phone"`
+		Contact string `validate:"required_without=Email"`
+	}
+
+	verr := validator.New().Struct(Input{Phone: "+251911000000"})
+	fieldErrors := error.MapValidationErrors(verr.(validator.ValidationErrors))
+	if len(fieldErrors) != 2 {
+		t.Fatalf("expected 2 field errors, got %d", len(fieldErrors))
+	}
+
+	expected := []string{
+		"Country is required when Phone is present",
+		"Contact is required when Email is absent",
+	}
+	for i, fe := range fieldErrors {
+		if fe.StatusCode != status.BadRequestMissingField {
+			t.Errorf("%s: unexpected status code %d", fe.Field, fe.StatusCode)
+		}
+		if fe.Reason != expected[i] {
+			t.Errorf("unexpected reason.\nExpected: %q\nGot: %q", expected[i], fe.Reason)
+		}
+	}
+}
+
+func TestMapValidationErrors_RequiredAllReasons(t *testing.T) {
+	type Input struct {
+		Street  string
+		City    string
+		Zip     string
+		Email   string
+		Phone   string
+		Country string `validate:"required_with_all=Street City Zip"`
+		Contact string `validate:"required_without_all=Email Phone"`
+	}
+
+	verr := validator.New().Struct(Input{Street: "Bole Road", City: "Addis Ababa", Zip: "1000"})
+	fieldErrors := error.MapValidationErrors(verr.(validator.ValidationErrors))
+	if len(fieldErrors) != 2 {
+		t.Fatalf("expected 2 field errors, got %d", len(fieldErrors))
+	}
+
+	expected := []string{
+		"Country is required when Street, City, and Zip are all present",
+		"Contact is required when Email and Phone are all absent",
+	}
+	for i, fe := range fieldErrors {
+		if fe.StatusCode != status.BadRequestMissingField {
+			t.Errorf("%s: unexpected status code %d", fe.Field, fe.StatusCode)
+		}
+		if fe.Reason != expected[i] {
+			t.Errorf("unexpected reason.\nExpected: %q\nGot: %q", expected[i], fe.Reason)
+		}
+	}
+}
+
+func TestMapValidationErrors_UniqueReason(t *testing.T) {
+	fe := validateField(t, []string{"a", "b", "a"}, "unique")
+	if fe.Reason != "Field must not contain duplicate values" {
+		t.Errorf("unexpected reason for primitive slice: %q", fe.Reason)
+	}
+
+	type Item struct{ Name string }
+	fe = validateField(t, []Item{{Name: "x"}, {Name: "x"}}, "unique=Name")
+	if fe.Reason != "Field must not contain items with duplicate Name values" {
+		t.Errorf("unexpected reason for struct slice: %q", fe.Reason)
+	}
+	if fe.StatusCode != status.BadRequestInvalidValue {
+		t.Errorf("unexpected status code: %d", fe.StatusCode)
+	}
+}
+
+func TestFromFieldErrors_Precedence(t *testing.T) {
+	fieldErrors := []*error.FieldValidationError{
+		{Field: "email", Reason: "email must be a valid email", ValidationTag: "email", StatusCode: status.BadRequestInvalidFormat},
+		{Field: "customer_id", Reason: "customer_id references a missing customer", ValidationTag: "exists", StatusCode: status.NotFoundResource},
+		{Field: "name", Reason: "name is required", ValidationTag: "required", StatusCode: status.BadRequestMissingField},
+	}
+
+	err := error.FromFieldErrors(fieldErrors)
+
+	if err.PublicStatusCode != status.NotFoundResource {
+		t.Errorf("expected the 404-coded field to win, got %d", err.PublicStatusCode)
+	}
+	if err.HTTPStatus() != 404 {
+		t.Errorf("unexpected HTTP status: %d", err.HTTPStatus())
+	}
+	for i, fe := range err.FieldErrors() {
+		if fe.StatusCode != fieldErrors[i].StatusCode {
+			t.Errorf("%s: expected per-field status %d to be kept, got %d", fe.Field, fieldErrors[i].StatusCode, fe.StatusCode)
+		}
+	}
+
+	onlyBadRequests := error.FromFieldErrors([]*error.FieldValidationError{fieldErrors[0], fieldErrors[2]})
+	if onlyBadRequests.PublicStatusCode != status.BadRequest {
+		t.Errorf("expected mixed 400 codes to collapse to BadRequest, got %d", onlyBadRequests.PublicStatusCode)
+	}
+}
+
+func TestFromFieldErrors_FormLevel(t *testing.T) {
+	err := error.FromFieldErrors([]*error.FieldValidationError{
+		{Field: "name", Reason: "name is required", ValidationTag: "required", StatusCode: status.BadRequestMissingField},
+		error.FormError("contact_method", "at least one contact method is required"),
+	})
+
+	if got := err.PublicMetaData["form_errors"]; got != "at least one contact method is required" {
+		t.Errorf("unexpected form_errors metadata: %q", got)
+	}
+	if got := err.PublicMetaData["fields"]; got != "name" {
+		t.Errorf("expected form-level entry to be kept out of fields, got %q", got)
+	}
+	if got := err.PublicMetaData["failures"]; got != "name: name is required" {
+		t.Errorf("expected form-level entry to be kept out of failures, got %q", got)
+	}
+	if !err.FieldErrors()[1].IsFormLevel() {
+		t.Error("expected FormError to be form-level")
+	}
+
+	fieldsOnly := error.FromFieldErrors(err.FieldErrors()[:1])
+	if _, ok := fieldsOnly.PublicMetaData["form_errors"]; ok {
+		t.Error("expected no form_errors key without form-level entries")
+	}
+}
+
+func TestEchoInvalidEnumValue(t *testing.T) {
+	error.EchoInvalidEnumValue(true)
+	defer error.EchoInvalidEnumValue(false)
+
+	fe := validateField(t, "purple", "oneof=red green blue")
+	expected := "'purple' is not valid; Field must be one of: red, green, blue"
+	if fe.Reason != expected {
+		t.Errorf("unexpected reason.\nExpected: %q\nGot: %q", expected, fe.Reason)
+	}
+
+	fe = validateField(t, strings.Repeat("x", 100), "oneof=red green blue")
+	if !strings.HasPrefix(fe.Reason, "'"+strings.Repeat("x", 32)+"...'") {
+		t.Errorf("expected a long value to be truncated, got %q", fe.Reason)
+	}
+}
+
+func TestError_WithFieldErrors(t *testing.T) {
+	err := error.FromFieldErrors([]*error.FieldValidationError{
+		error.FormError("exclusive", "choose either email or phone"),
+	})
+
+	err.WithFieldErrors([]*error.FieldValidationError{
+		{Field: "email", Reason: "email must be a valid email", ValidationTag: "email", StatusCode: status.BadRequestInvalidFormat},
+		{Field: "name", Reason: "name is required", ValidationTag: "required", StatusCode: status.BadRequestMissingField},
+	})
+
+	if got := err.PublicMetaData["fields"]; got != "email, name" {
+		t.Errorf("unexpected fields metadata: %q", got)
+	}
+	if got := err.PublicMetaData["failures"]; got != "email: email must be a valid email; name: name is required" {
+		t.Errorf("unexpected failures metadata: %q", got)
+	}
+	if _, ok := err.PublicMetaData["form_errors"]; ok {
+		t.Error("expected stale form_errors metadata to be removed")
+	}
+	if len(err.FieldErrors()) != 2 {
+		t.Errorf("expected the attached field errors, got %d", len(err.FieldErrors()))
+	}
+}
+
+func TestUnregisterValidationTag(t *testing.T) {
+	error.RegisterValidationTag("email", status.BadRequestInvalidValue, nil)
+	error.UnregisterValidationTag("email")
+
+	input := struct {
+		Email string `validate:"email"`
+	}{Email: "not-an-email"}
+	fe := error.MapValidationErrors(validator.New().Struct(input).(validator.ValidationErrors))[0]
+	if fe.StatusCode != status.BadRequestInvalidFormat {
+		t.Errorf("expected the built-in code after unregistering, got %d", fe.StatusCode)
+	}
+}
@@ -0,0 +1,69 @@
+package status
+
+import "connectrpc.com/connect"
+
+// connectCodeOverrides holds codes whose connect code differs from the one
+// implied by their category.
+var connectCodeOverrides = map[StatusCode]connect.Code{
+	BadRequestOutOfRange:            connect.CodeOutOfRange,
+	BadRequestMethodNotAllowed:      connect.CodeUnimplemented,
+	BadRequestClientClosed:          connect.CodeCanceled,
+	BadRequestExpectationFailed:     connect.CodeFailedPrecondition,
+	ForbiddenResourceState:          connect.CodeFailedPrecondition,
+	ConflictDuplicateData:           connect.CodeAlreadyExists,
+	ConflictResourceInUse:           connect.CodeFailedPrecondition,
+	ServerErrorServiceCommunication: connect.CodeUnavailable,
+	ServerErrorTimeout:              connect.CodeDeadlineExceeded,
+	ServerErrorUnavailable:          connect.CodeUnavailable,
+}
+
+// connectCategoryCodes maps each category to its connect code.
+var connectCategoryCodes = map[StatusCode]connect.Code{
+	BadRequest:   connect.CodeInvalidArgument,
+	Unauthorized: connect.CodeUnauthenticated,
+	Forbidden:    connect.CodePermissionDenied,
+	NotFound:     connect.CodeNotFound,
+	Conflict:     connect.CodeAborted,
+	ServerError:  connect.CodeInternal,
+}
+
+// ToConnectCode returns the connect-go error code for the given StatusCode,
+// falling back to the code of its category. Unknown codes, and codes that
+// are not errors such as MultiStatus, map to connect.CodeUnknown.
+func ToConnectCode(code StatusCode) connect.Code {
+	if connectCode, ok := connectCodeOverrides[code]; ok {
+		return connectCode
+	}
+	if connectCode, ok := connectCategoryCodes[Group(code)]; ok && IsRegistered(code) {
+		return connectCode
+	}
+	return connect.CodeUnknown
+}
+
+// fromConnectCodes maps connect codes back to the closest StatusCode.
+var fromConnectCodes = map[connect.Code]StatusCode{
+	connect.CodeCanceled:           BadRequestClientClosed,
+	connect.CodeInvalidArgument:    BadRequest,
+	connect.CodeDeadlineExceeded:   ServerErrorTimeout,
+	connect.CodeNotFound:           NotFound,
+	connect.CodeAlreadyExists:      ConflictDuplicateData,
+	connect.CodePermissionDenied:   Forbidden,
+	connect.CodeResourceExhausted:  ServerErrorUnavailable,
+	connect.CodeFailedPrecondition: ForbiddenResourceState,
+	connect.CodeAborted:            Conflict,
+	connect.CodeOutOfRange:         BadRequestOutOfRange,
+	connect.CodeUnimplemented:      BadRequestMethodNotAllowed,
+	connect.CodeInternal:           ServerError,
+	connect.CodeUnavailable:        ServerErrorUnavailable,
+	connect.CodeDataLoss:           ServerError,
+	connect.CodeUnauthenticated:    Unauthorized,
+}
+
+// FromConnectCode returns the StatusCode closest to the given connect-go
+// error code. Unknown codes map to ServerError.
+func FromConnectCode(code connect.Code) StatusCode {
+	if statusCode, ok := fromConnectCodes[code]; ok {
+		return statusCode
+	}
+	return ServerError
+}
@@ -0,0 +1,68 @@
+package status_test
+
+import (
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/beka-birhanu/toddler/status"
+)
+
+func TestToConnectCode(t *testing.T) {
+	tests := []struct {
+		code status.StatusCode
+		want connect.Code
+	}{
+		{status.BadRequestMissingField, connect.CodeInvalidArgument},
+		{status.BadRequestOutOfRange, connect.CodeOutOfRange},
+		{status.BadRequestClientClosed, connect.CodeCanceled},
+		{status.UnauthorizedInvalidToken, connect.CodeUnauthenticated},
+		{status.ForbiddenOnlyOwners, connect.CodePermissionDenied},
+		{status.ForbiddenResourceState, connect.CodeFailedPrecondition},
+		{status.NotFoundResource, connect.CodeNotFound},
+		{status.Conflict, connect.CodeAborted},
+		{status.ConflictDuplicateData, connect.CodeAlreadyExists},
+		{status.ServerErrorDatabase, connect.CodeInternal},
+		{status.ServerErrorTimeout, connect.CodeDeadlineExceeded},
+		{status.ServerErrorUnavailable, connect.CodeUnavailable},
+		{status.StatusCode(1234), connect.CodeUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := status.ToConnectCode(tt.code); got != tt.want {
+			t.Errorf("ToConnectCode(%d) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestFromConnectCode(t *testing.T) {
+	tests := []struct {
+		code connect.Code
+		want status.StatusCode
+	}{
+		{connect.CodeInvalidArgument, status.BadRequest},
+		{connect.CodeUnauthenticated, status.Unauthorized},
+		{connect.CodePermissionDenied, status.Forbidden},
+		{connect.CodeNotFound, status.NotFound},
+		{connect.CodeAlreadyExists, status.ConflictDuplicateData},
+		{connect.CodeDeadlineExceeded, status.ServerErrorTimeout},
+		{connect.CodeInternal, status.ServerError},
+		{connect.CodeUnknown, status.ServerError},
+	}
+
+	for _, tt := range tests {
+		if got := status.FromConnectCode(tt.code); got != tt.want {
+			t.Errorf("FromConnectCode(%v) = %d, want %d", tt.code, got, tt.want)
+		}
+	}
+
+	// Mapped error codes round-trip to the same connect code.
+	for _, code := range status.AllCodes() {
+		if status.HTTPStatus(code) < 400 {
+			continue
+		}
+		connectCode := status.ToConnectCode(code)
+		if back := status.ToConnectCode(status.FromConnectCode(connectCode)); back != connectCode {
+			t.Errorf("%d: connect code %v round-tripped to %v", code, connectCode, back)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package status
+
+import "net/http"
+
+// StatusClientClosedRequest is the non-standard (nginx) status for a request
+// the client abandoned before a response was sent.
+const StatusClientClosedRequest = 499
+
+// httpStatusOverrides holds codes whose HTTP status differs from the one
+// implied by their first three digits.
+var httpStatusOverrides = map[StatusCode]int{
+	BadRequestMethodNotAllowed:      http.StatusMethodNotAllowed,
+	BadRequestClientClosed:          StatusClientClosedRequest,
+	ConflictLocked:                  http.StatusLocked,
+	ServerErrorServiceCommunication: http.StatusBadGateway,
+	ServerErrorTimeout:              http.StatusGatewayTimeout,
+	ServerErrorUnavailable:          http.StatusServiceUnavailable,
+}
+
+// HTTPStatus returns the HTTP status code for the given StatusCode.
+// Since every code extends an HTTP status by one digit, it is derived from
+// the first three digits unless overridden. Unknown codes map to 500.
+func HTTPStatus(code StatusCode) int {
+	if httpStatus, ok := httpStatusOverrides[code]; ok {
+		return httpStatus
+	}
+	if !IsRegistered(code) {
+		return http.StatusInternalServerError
+	}
+	return int(code) / 10
+}
@@ -0,0 +1,36 @@
+package status_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/beka-birhanu/toddler/status"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		code status.StatusCode
+		want int
+	}{
+		{status.BadRequestMissingField, http.StatusBadRequest},
+		{status.BadRequestMethodNotAllowed, http.StatusMethodNotAllowed},
+		{status.BadRequestClientClosed, status.StatusClientClosedRequest},
+		{status.UnauthorizedInvalidToken, http.StatusUnauthorized},
+		{status.ForbiddenOnlyOwners, http.StatusForbidden},
+		{status.NotFoundResource, http.StatusNotFound},
+		{status.ConflictDuplicateData, http.StatusConflict},
+		{status.ConflictLocked, http.StatusLocked},
+		{status.BadRequestExpectationFailed, http.StatusExpectationFailed},
+		{status.ServerErrorDatabase, http.StatusInternalServerError},
+		{status.ServerErrorServiceCommunication, http.StatusBadGateway},
+		{status.ServerErrorTimeout, http.StatusGatewayTimeout},
+		{status.ServerErrorUnavailable, http.StatusServiceUnavailable},
+		{status.StatusCode(1234), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		if got := status.HTTPStatus(tt.code); got != tt.want {
+			t.Errorf("HTTPStatus(%d): got %d, want %d", tt.code, got, tt.want)
+		}
+	}
+}
@@ -5,6 +5,7 @@
 // aligned with HTTP categories (e.g., 4000s for Bad Request, 5000s for Server Error).
 //
 // Example categories:
+//   - 2070–2079: Multi-Status (partial success of bulk requests)
 //   - 4000–4009: Bad Request (client input errors)
 //   - 4010–4019: Unauthorized (auth failures)
 //   - 4030–4039: Forbidden (access control)
@@ -15,21 +16,41 @@
 // can be mapped to user-friendly messages or used in API responses.
 package status
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
 
 // StatusCode defines custom application-specific status codes.
 type StatusCode int
 
+// Partial success (2070 - 2079)
+const (
+	MultiStatus StatusCode = 2070 // Mixed success and failure, e.g. in a bulk request
+)
+
 // BadRequest-related errors (4000 - 4009)
 const (
-	BadRequest                StatusCode = 4000 + iota // Generic bad request
-	BadRequestMissingField                             // Required field missing
-	BadRequestTypeMismatch                             // Type mismatch
-	BadRequestFieldConstraint                          // Field constraint failed
-	BadRequestInvalidFormat                            // Invalid format
-	BadRequestOutOfRange                               // Value out of range
-	BadRequestInvalidValue                             // Invalid value
-	BadRequestEnumViolation                            // Enum value not allowed
+	BadRequest                 StatusCode = 4000 + iota // Generic bad request
+	BadRequestMissingField                              // Required field missing
+	BadRequestTypeMismatch                              // Type mismatch
+	BadRequestFieldConstraint                           // Field constraint failed
+	BadRequestInvalidFormat                             // Invalid format
+	BadRequestOutOfRange                                // Value out of range
+	BadRequestInvalidValue                              // Invalid value
+	BadRequestEnumViolation                             // Enum value not allowed
+	BadRequestMethodNotAllowed                          // HTTP method not allowed
+	BadRequestClientClosed                              // Client closed the request
+)
+
+// BadRequest-related errors beyond 4009. The 4000 - 4009 range is full, so
+// these codes carry the number of their HTTP status and are registered in
+// the BadRequest category through categoryOverrides.
+const (
+	BadRequestExpectationFailed StatusCode = 4170 // Expect or conditional request header not met
 )
 
 // Unauthorized-related errors (4010 - 4019)
@@ -45,6 +66,7 @@ const (
 	Forbidden                   StatusCode = 4030 + iota // Generic forbidden
 	ForbiddenNotEnoughPrivilege                          // Insufficient privileges
 	ForbiddenOnlyOwners                                  // Allowed for resource owners only
+	ForbiddenResourceState                               // Action not allowed in the resource's current state
 )
 
 // NotFound-related errors (4040 - 4049)
@@ -57,6 +79,8 @@ const (
 const (
 	Conflict              StatusCode = 4090 + iota // Generic conflict
 	ConflictDuplicateData                          // Conflict Duplicate Data
+	ConflictResourceInUse                          // Resource still referenced elsewhere
+	ConflictLocked                                 // Resource is locked
 )
 
 // Server-related errors (5000 - 5009)
@@ -64,10 +88,13 @@ const (
 	ServerError                     StatusCode = 5000 + iota // Generic server error
 	ServerErrorDatabase                                      // Database error
 	ServerErrorServiceCommunication                          // Service communication failed
+	ServerErrorTimeout                                       // Operation timed out
+	ServerErrorUnavailable                                   // Service temporarily unavailable
 )
 
 // A map to associate StatusCode with error names.
 var statusCodeMap = map[StatusCode]string{
+	MultiStatus:                     "MultiStatus",
 	BadRequest:                      "BadRequest",
 	BadRequestMissingField:          "BadRequest_MissingField",
 	BadRequestTypeMismatch:          "BadRequest_TypeMismatch",
@@ -76,6 +103,9 @@ var statusCodeMap = map[StatusCode]string{
 	BadRequestOutOfRange:            "BadRequest_OutOfRange",
 	BadRequestInvalidValue:          "BadRequest_InvalidValue",
 	BadRequestEnumViolation:         "BadRequest_EnumViolation",
+	BadRequestMethodNotAllowed:      "BadRequest_MethodNotAllowed",
+	BadRequestClientClosed:          "BadRequest_ClientClosed",
+	BadRequestExpectationFailed:     "BadRequest_ExpectationFailed",
 	Unauthorized:                    "Unauthorized",
 	UnauthorizedInvalidCredential:   "Unauthorized_InvalidCredential",
 	UnauthorizedTokenRequired:       "Unauthorized_TokenRequired",
@@ -83,13 +113,18 @@ var statusCodeMap = map[StatusCode]string{
 	Forbidden:                       "Forbidden",
 	ForbiddenNotEnoughPrivilege:     "Forbidden_NotEnoughPrivilege",
 	ForbiddenOnlyOwners:             "Forbidden_OnlyOwners",
+	ForbiddenResourceState:          "Forbidden_ResourceState",
 	NotFound:                        "NotFound",
 	NotFoundResource:                "NotFound_Resource",
 	Conflict:                        "Conflict",
 	ConflictDuplicateData:           "Conflict_DuplicateData",
+	ConflictResourceInUse:           "Conflict_ResourceInUse",
+	ConflictLocked:                  "Conflict_Locked",
 	ServerError:                     "ServerError",
 	ServerErrorDatabase:             "ServerError_Database",
 	ServerErrorServiceCommunication: "ServerError_ServiceCommunication",
+	ServerErrorTimeout:              "ServerError_Timeout",
+	ServerErrorUnavailable:          "ServerError_Unavailable",
 }
 
 // GetErrorName takes a StatusCode and returns the corresponding error name as a string.
@@ -100,6 +135,193 @@ func GetErrorName(code StatusCode) string {
 	return fmt.Sprintf("UnknownStatusCode-%d", code)
 }
 
+// Error implements the error interface so a bare StatusCode can be used as
+// an errors.Is target, e.g. errors.Is(err, status.NotFoundResource).
+func (c StatusCode) Error() string {
+	return GetErrorLabel(c)
+}
+
+// GetErrorLabel returns the error name together with the numeric code,
+// e.g. "BadRequest_MissingField(4001)".
+func GetErrorLabel(code StatusCode) string {
+	return fmt.Sprintf("%s(%d)", GetErrorName(code), code)
+}
+
+// Slug returns a stable, machine-readable identifier for the code derived
+// from its name, e.g. "bad_request.missing_field" for BadRequestMissingField.
+// It returns "unknown" for unregistered codes.
+func Slug(code StatusCode) string {
+	name, exists := statusCodeMap[code]
+	if !exists {
+		return "unknown"
+	}
+	parts := strings.Split(name, "_")
+	for i, part := range parts {
+		parts[i] = snakeCase(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// snakeCase converts a CamelCase identifier to snake_case.
+func snakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// IsRegistered reports whether the given code is a known StatusCode.
+func IsRegistered(code StatusCode) bool {
+	_, exists := statusCodeMap[code]
+	return exists
+}
+
+// defaultMessageMap holds generic, public-safe messages per category.
+var defaultMessageMap = map[StatusCode]string{
+	MultiStatus:                 "Some items could not be processed.",
+	BadRequest:                  "The request is invalid.",
+	BadRequestExpectationFailed: "The request's expectations could not be met.",
+	Unauthorized:                "Authentication is required to access this resource.",
+	Forbidden:                   "You don't have permission to perform this action.",
+	NotFound:                    "The requested resource was not found.",
+	Conflict:                    "The request conflicts with the current state of the resource.",
+	ServerError:                 "A server error occurred. Please try again later.",
+}
+
+// DefaultMessage returns a generic public-safe message for the given code,
+// falling back to the message of its category.
+func DefaultMessage(code StatusCode) string {
+	if msg, ok := defaultMessageMap[code]; ok {
+		return msg
+	}
+	if msg, ok := defaultMessageMap[Group(code)]; ok {
+		return msg
+	}
+	return "An unexpected error occurred."
+}
+
+// actionHintMap holds short, generic "what to do next" guidance per code.
+var actionHintMap = map[StatusCode]string{
+	BadRequest:                    "Check the request and try again.",
+	BadRequestMissingField:        "Check required fields.",
+	BadRequestTypeMismatch:        "Check the types of the submitted values.",
+	BadRequestInvalidFormat:       "Check the format of the submitted values.",
+	BadRequestOutOfRange:          "Check the allowed ranges of the submitted values.",
+	BadRequestEnumViolation:       "Use one of the allowed values.",
+	BadRequestMethodNotAllowed:    "Use one of the allowed HTTP methods.",
+	BadRequestExpectationFailed:   "Retry without the Expect or conditional headers.",
+	Unauthorized:                  "Log in and try again.",
+	UnauthorizedInvalidCredential: "Check your credentials.",
+	UnauthorizedTokenRequired:     "Log in again.",
+	UnauthorizedInvalidToken:      "Log in again.",
+	Forbidden:                     "Contact an administrator if you need access.",
+	NotFound:                      "Check the identifier and try again.",
+	Conflict:                      "Refresh the resource and try again.",
+	ConflictDuplicateData:         "Use a different value.",
+	ServerError:                   "Retry later.",
+}
+
+// ActionHint returns short, public-safe guidance on what the client can do
+// about an error with the given code, falling back to the hint of its
+// category. It returns "" when there is no hint.
+func ActionHint(code StatusCode) string {
+	if hint, ok := actionHintMap[code]; ok {
+		return hint
+	}
+	return actionHintMap[Group(code)]
+}
+
+// docURLMap holds optional documentation URLs per code.
+var docURLMap = map[StatusCode]string{}
+
+// RegisterDocURL associates a documentation URL with code.
+func RegisterDocURL(code StatusCode, url string) {
+	docURLMap[code] = url
+}
+
+// DocURL returns the documentation URL registered for code, or "" if none.
+func DocURL(code StatusCode) string {
+	return docURLMap[code]
+}
+
+// AllCodes returns every registered StatusCode in ascending order.
+func AllCodes() []StatusCode {
+	codes := make([]StatusCode, 0, len(statusCodeMap))
+	for code := range statusCodeMap {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
+
+// categoryOverrides holds codes numbered outside the range of the category
+// they belong to.
+var categoryOverrides = map[StatusCode]StatusCode{
+	BadRequestExpectationFailed: BadRequest,
+}
+
+// Group returns the generic base code of the category the given code belongs
+// to, e.g. Group(BadRequestMissingField) == BadRequest.
+func Group(code StatusCode) StatusCode {
+	if group, ok := categoryOverrides[code]; ok {
+		return group
+	}
+	return code - code%10
+}
+
+// Category returns the name of the category the code belongs to, e.g.
+// "BadRequest" for BadRequestMissingField, or "Unknown" if the category is
+// not registered.
+func (code StatusCode) Category() string {
+	if name, exists := statusCodeMap[Group(code)]; exists {
+		return name
+	}
+	return "Unknown"
+}
+
+// severityRank orders error categories by precedence, most severe last.
+var severityRank = map[StatusCode]int{
+	BadRequest:   1,
+	NotFound:     2,
+	Unauthorized: 3,
+	Forbidden:    4,
+	Conflict:     5,
+	ServerError:  6,
+}
+
+// MoreSevere reports whether a takes precedence over b when several failures
+// must be summarized by one code. Categories rank server > conflict >
+// forbidden > unauthorized > not-found > bad-request; within a category a
+// specific code ranks above the generic one. Codes outside these categories
+// rank below all of them.
+func MoreSevere(a, b StatusCode) bool {
+	rankA, rankB := severityRank[Group(a)], severityRank[Group(b)]
+	if rankA != rankB {
+		return rankA > rankB
+	}
+	return Group(a) == Group(b) && a != Group(a) && b == Group(b)
+}
+
+// CodesInCategory returns all registered codes, including the generic base
+// code, that belong to the same category as base, in ascending order.
+func CodesInCategory(base StatusCode) []StatusCode {
+	group := Group(base)
+	var codes []StatusCode
+	for _, code := range AllCodes() {
+		if Group(code) == group {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
 // suppressMap maps over-detailed status codes to generalized public-safe ones.
 var suppressMap = map[StatusCode]StatusCode{
 	BadRequestOutOfRange:            BadRequest,
@@ -110,9 +332,46 @@ var suppressMap = map[StatusCode]StatusCode{
 	ServerErrorServiceCommunication: ServerError,
 }
 
+// registeredSuppressions holds suppress rules added at runtime via RegisterSuppression.
+var registeredSuppressions = map[StatusCode]StatusCode{}
+
+// RegisterSuppression makes SuppressOverDetail neutralize detailed into
+// general, in addition to the built-in rules. A registered rule takes
+// precedence over a built-in one for the same code. It is meant to be
+// called at startup.
+func RegisterSuppression(detailed, general StatusCode) {
+	registeredSuppressions[detailed] = general
+}
+
+// UnregisterSuppression removes a rule added with RegisterSuppression,
+// restoring the built-in behavior for detailed.
+func UnregisterSuppression(detailed StatusCode) {
+	delete(registeredSuppressions, detailed)
+}
+
+// ValidateSuppressions checks that every built-in and registered
+// suppression targets a registered category base code (e.g. BadRequest),
+// returning an error describing each misconfigured rule.
+func ValidateSuppressions() error {
+	var errs []error
+	check := func(source string, rules map[StatusCode]StatusCode) {
+		for detailed, general := range rules {
+			if !IsRegistered(general) || Group(general) != general {
+				errs = append(errs, fmt.Errorf("%s suppression %s -> %d: target is not a registered category code", source, GetErrorName(detailed), general))
+			}
+		}
+	}
+	check("built-in", suppressMap)
+	check("registered", registeredSuppressions)
+	return errors.Join(errs...)
+}
+
 // SuppressOverDetail returns a neutralized version of the given StatusCode.
 // If a mapping is not found, it returns the original code.
 func SuppressOverDetail(code StatusCode) StatusCode {
+	if suppressed, ok := registeredSuppressions[code]; ok {
+		return suppressed
+	}
 	if suppressed, ok := suppressMap[code]; ok {
 		return suppressed
 	}
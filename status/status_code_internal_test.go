@@ -0,0 +1,29 @@
+package status
+
+import "testing"
+
+func TestValidateSuppressions(t *testing.T) {
+	if err := ValidateSuppressions(); err != nil {
+		t.Fatalf("expected built-in suppressions to be valid, got: %v", err)
+	}
+
+	previous, hadPrevious := registeredSuppressions[BadRequestTypeMismatch]
+	defer func() {
+		if hadPrevious {
+			registeredSuppressions[BadRequestTypeMismatch] = previous
+		} else {
+			delete(registeredSuppressions, BadRequestTypeMismatch)
+		}
+	}()
+
+	registeredSuppressions[BadRequestTypeMismatch] = BadRequestMissingField
+
+	if err := ValidateSuppressions(); err == nil {
+		t.Error("expected a suppression targeting a specific code to be rejected")
+	}
+
+	registeredSuppressions[BadRequestTypeMismatch] = StatusCode(4050)
+	if err := ValidateSuppressions(); err == nil {
+		t.Error("expected a suppression targeting an unregistered code to be rejected")
+	}
+}
@@ -0,0 +1,163 @@
+package status_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/beka-birhanu/toddler/status"
+)
+
+func TestCodesInCategory(t *testing.T) {
+	expected := []status.StatusCode{
+		status.BadRequest,
+		status.BadRequestMissingField,
+		status.BadRequestTypeMismatch,
+		status.BadRequestFieldConstraint,
+		status.BadRequestInvalidFormat,
+		status.BadRequestOutOfRange,
+		status.BadRequestInvalidValue,
+		status.BadRequestEnumViolation,
+		status.BadRequestMethodNotAllowed,
+		status.BadRequestClientClosed,
+		status.BadRequestExpectationFailed,
+	}
+
+	actual := status.CodesInCategory(status.BadRequest)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected codes.\nExpected: %v\nGot: %v", expected, actual)
+	}
+
+	if got := status.CodesInCategory(status.BadRequestOutOfRange); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected a specific code to resolve to its category, got %v", got)
+	}
+}
+
+func TestGetErrorName(t *testing.T) {
+	if got := status.GetErrorName(status.ForbiddenResourceState); got != "Forbidden_ResourceState" {
+		t.Errorf("unexpected name: %q", got)
+	}
+	if got := status.GetErrorName(status.StatusCode(1234)); got != "UnknownStatusCode-1234" {
+		t.Errorf("unexpected name for unknown code: %q", got)
+	}
+}
+
+func TestCategory_OutOfRangeCode(t *testing.T) {
+	if got := status.Group(status.BadRequestExpectationFailed); got != status.BadRequest {
+		t.Errorf("expected BadRequestExpectationFailed in the BadRequest group, got %d", got)
+	}
+	if got := status.BadRequestExpectationFailed.Category(); got != "BadRequest" {
+		t.Errorf("unexpected category: %q", got)
+	}
+	if status.ActionHint(status.BadRequestExpectationFailed) == status.ActionHint(status.BadRequest) {
+		t.Error("expected a dedicated action hint")
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		code status.StatusCode
+		want string
+	}{
+		{status.BadRequestMissingField, "bad_request.missing_field"},
+		{status.ServerErrorServiceCommunication, "server_error.service_communication"},
+		{status.NotFound, "not_found"},
+		{status.StatusCode(1234), "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := status.Slug(tt.code); got != tt.want {
+			t.Errorf("Slug(%d): got %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestMoreSevere(t *testing.T) {
+	tests := []struct {
+		a, b status.StatusCode
+		want bool
+	}{
+		{status.ServerError, status.ConflictDuplicateData, true},
+		{status.ConflictLocked, status.ForbiddenOnlyOwners, true},
+		{status.Forbidden, status.UnauthorizedInvalidToken, true},
+		{status.UnauthorizedTokenRequired, status.NotFoundResource, true},
+		{status.NotFound, status.BadRequestMissingField, true},
+		{status.BadRequestMissingField, status.NotFound, false},
+		{status.BadRequestMissingField, status.BadRequest, true},
+		{status.BadRequest, status.BadRequestMissingField, false},
+		{status.BadRequestMissingField, status.BadRequestInvalidFormat, false},
+		{status.ServerErrorDatabase, status.ServerErrorDatabase, false},
+		{status.BadRequest, status.MultiStatus, true},
+	}
+
+	for _, tt := range tests {
+		if got := status.MoreSevere(tt.a, tt.b); got != tt.want {
+			t.Errorf("MoreSevere(%d, %d): got %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestActionHint(t *testing.T) {
+	tests := []struct {
+		code status.StatusCode
+		want string
+	}{
+		{status.BadRequestMissingField, "Check required fields."},
+		{status.UnauthorizedInvalidToken, "Log in again."},
+		{status.ServerErrorDatabase, "Retry later."},
+		{status.StatusCode(1234), ""},
+	}
+
+	for _, tt := range tests {
+		if got := status.ActionHint(tt.code); got != tt.want {
+			t.Errorf("ActionHint(%d): got %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestGetErrorLabel(t *testing.T) {
+	if got := status.GetErrorLabel(status.BadRequestMissingField); got != "BadRequest_MissingField(4001)" {
+		t.Errorf("unexpected label: %q", got)
+	}
+	if got := status.GetErrorLabel(status.StatusCode(1234)); got != "UnknownStatusCode-1234(1234)" {
+		t.Errorf("unexpected label for unknown code: %q", got)
+	}
+}
+
+func TestRegisterSuppression(t *testing.T) {
+	if got := status.SuppressOverDetail(status.BadRequestTypeMismatch); got != status.BadRequestTypeMismatch {
+		t.Fatalf("expected no built-in suppression, got %d", got)
+	}
+
+	status.RegisterSuppression(status.BadRequestTypeMismatch, status.BadRequest)
+	t.Cleanup(func() { status.UnregisterSuppression(status.BadRequestTypeMismatch) })
+
+	if got := status.SuppressOverDetail(status.BadRequestTypeMismatch); got != status.BadRequest {
+		t.Errorf("expected registered suppression to apply, got %d", got)
+	}
+	if got := status.SuppressOverDetail(status.ServerErrorDatabase); got != status.ServerError {
+		t.Errorf("expected built-in suppression to still apply, got %d", got)
+	}
+
+	status.UnregisterSuppression(status.BadRequestTypeMismatch)
+	if got := status.SuppressOverDetail(status.BadRequestTypeMismatch); got != status.BadRequestTypeMismatch {
+		t.Errorf("expected suppression to be removed, got %d", got)
+	}
+}
+
+func TestStatusCode_Category(t *testing.T) {
+	tests := []struct {
+		code status.StatusCode
+		want string
+	}{
+		{status.BadRequestMissingField, "BadRequest"},
+		{status.NotFoundResource, "NotFound"},
+		{status.ServerError, "ServerError"},
+		{status.StatusCode(1234), "Unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.code.Category(); got != tt.want {
+			t.Errorf("Category(%d) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}